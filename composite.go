@@ -0,0 +1,192 @@
+package giniapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// content types of the partial/composite document model
+const (
+	partialContentType   = "application/vnd.gini.v2.partial+%s"
+	compositeContentType = "application/vnd.gini.v2.composite+json"
+)
+
+// PartialDocument references a partial document of a composite document
+type PartialDocument struct {
+	// Document is the link to the partial document
+	Document string `json:"document"`
+	// RotationDelta rotates the pages of the partial clockwise by a multiple
+	// of 90 degrees
+	RotationDelta int `json:"rotationDelta"`
+}
+
+// Partial returns the reference to the document with the given rotation to
+// compose it with CreateComposite
+func (d *Document) Partial(rotation int) PartialDocument {
+	return PartialDocument{Document: d.Links.Document, RotationDelta: rotation}
+}
+
+// UploadPartial uploads a single page (image or PDF) as partial document.
+// Partial documents are not analyzed, combine them with CreateComposite.
+// The ContentType of the options is detected from the content if empty,
+// other content than images and PDFs is rejected.
+func (api *APIClient) UploadPartial(ctx context.Context, document io.Reader, options UploadOptions) (*Document, APIResponse) {
+	if options.ContentType == "" {
+		size := readerSize(document)
+		buffered := bufio.NewReader(document)
+		head, _ := buffered.Peek(512)
+		options.ContentType = http.DetectContentType(head)
+
+		document = buffered
+		if size >= 0 {
+			document = &sizedReader{Reader: buffered, size: size}
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(options.ContentType)
+	if err != nil {
+		return nil, apiResponse(ErrUploadFailed, "", nil, fmt.Errorf("%s: %s", ErrUploadFailed, err))
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, fmt.Sprintf(partialContentType, "")):
+		// already a partial content type
+	case strings.HasPrefix(mediaType, "image/") || mediaType == "application/pdf":
+		options.ContentType = fmt.Sprintf(partialContentType, mediaType[strings.Index(mediaType, "/")+1:])
+	default:
+		return nil, apiResponse(ErrUploadFailed, "", nil, fmt.Errorf("%s: partial documents must be images or PDFs, not %s", ErrUploadFailed, mediaType))
+	}
+
+	return api.Upload(ctx, document, options)
+}
+
+// CreateComposite creates a document of the partial documents in the given
+// order. The composite document is analyzed like an upload, Poll it and
+// fetch its extractions as usual. FileName, DocType and UserIdentifier of
+// the options apply to the composite.
+func (api *APIClient) CreateComposite(ctx context.Context, partials []PartialDocument, options UploadOptions) (*Document, APIResponse) {
+	if len(partials) == 0 {
+		return nil, apiResponse(ErrCompositeInvalid, "", nil, fmt.Errorf("%s: no partial documents", ErrCompositeInvalid))
+	}
+
+	for _, partial := range partials {
+		if partial.Document == "" || partial.RotationDelta%90 != 0 {
+			return nil, apiResponse(ErrCompositeInvalid, "", nil, fmt.Errorf("%s: invalid partial document %+v", ErrCompositeInvalid, partial))
+		}
+	}
+
+	body, err := json.Marshal(map[string][]PartialDocument{"partialDocuments": partials})
+	if err != nil {
+		return nil, apiResponse(ErrCompositeInvalid, "", nil, err)
+	}
+
+	options.ContentType = compositeContentType
+
+	return api.Upload(ctx, bytes.NewReader(body), options)
+}
+
+// partials returns a copy of the partial documents of a composite document
+func (d *Document) partials() ([]PartialDocument, error) {
+	if len(d.PartialDocuments) == 0 {
+		return nil, fmt.Errorf("%s: %s is no composite document", ErrCompositeInvalid, d.ID)
+	}
+	return append([]PartialDocument(nil), d.PartialDocuments...), nil
+}
+
+// checkPartial returns an error unless index is a partial document
+func (d *Document) checkPartial(index int) error {
+	if index < 0 || index >= len(d.PartialDocuments) {
+		return fmt.Errorf("%s: no partial document %d", ErrCompositeInvalid, index)
+	}
+	return nil
+}
+
+// recompose creates a composite document of the partials and replaces the
+// document with it. The previous composite document is kept.
+func (d *Document) recompose(ctx context.Context, partials []PartialDocument) APIResponse {
+	created, resp := d.client.CreateComposite(ctx, partials, UploadOptions{FileName: d.Name, DocType: d.DocType, UserIdentifier: d.Owner})
+	if resp.Error != nil {
+		return resp
+	}
+
+	*d = *created
+
+	return apiResponse("update completed", d.ID, resp.HttpResponse, nil)
+}
+
+// RotatePartial rotates the pages of a partial document of a composite
+// document clockwise by a multiple of 90 degrees. Partial documents are
+// addressed by their index in PartialDocuments, which is the page index if
+// every partial is a single page (e.g. scanned pages). Documents are
+// immutable, so a new composite document is created and the document is
+// replaced by it, including Pages and PageCount.
+func (d *Document) RotatePartial(ctx context.Context, index, degrees int) APIResponse {
+	partials, err := d.partials()
+	if err != nil {
+		return apiResponse(ErrCompositeInvalid, d.ID, nil, err)
+	}
+
+	if err := d.checkPartial(index); err != nil {
+		return apiResponse(ErrCompositeInvalid, d.ID, nil, err)
+	}
+
+	if degrees%90 != 0 {
+		return apiResponse(ErrCompositeInvalid, d.ID, nil, fmt.Errorf("%s: rotation by %d degrees", ErrCompositeInvalid, degrees))
+	}
+
+	partials[index].RotationDelta = ((partials[index].RotationDelta+degrees)%360 + 360) % 360
+
+	return d.recompose(ctx, partials)
+}
+
+// ReorderPartials arranges the partial documents of a composite document in
+// a new order. Order lists all indexes in their new order, e.g. []int{1, 0}
+// swaps two partials. See RotatePartial for details.
+func (d *Document) ReorderPartials(ctx context.Context, order []int) APIResponse {
+	partials, err := d.partials()
+	if err != nil {
+		return apiResponse(ErrCompositeInvalid, d.ID, nil, err)
+	}
+
+	if len(order) != len(partials) {
+		return apiResponse(ErrCompositeInvalid, d.ID, nil, fmt.Errorf("%s: order of %d partials for %d partials", ErrCompositeInvalid, len(order), len(partials)))
+	}
+
+	reordered := make([]PartialDocument, len(order))
+	seen := make(map[int]bool, len(order))
+	for position, index := range order {
+		if d.checkPartial(index) != nil || seen[index] {
+			return apiResponse(ErrCompositeInvalid, d.ID, nil, fmt.Errorf("%s: invalid partial order %v", ErrCompositeInvalid, order))
+		}
+		seen[index] = true
+		reordered[position] = partials[index]
+	}
+
+	return d.recompose(ctx, reordered)
+}
+
+// RemovePartial removes a partial document of a composite document. The
+// last partial can not be removed. See RotatePartial for details.
+func (d *Document) RemovePartial(ctx context.Context, index int) APIResponse {
+	partials, err := d.partials()
+	if err != nil {
+		return apiResponse(ErrCompositeInvalid, d.ID, nil, err)
+	}
+
+	if err := d.checkPartial(index); err != nil {
+		return apiResponse(ErrCompositeInvalid, d.ID, nil, err)
+	}
+
+	if len(partials) == 1 {
+		return apiResponse(ErrCompositeInvalid, d.ID, nil, fmt.Errorf("%s: can not remove the last partial document", ErrCompositeInvalid))
+	}
+
+	return d.recompose(ctx, append(partials[:index], partials[index+1:]...))
+}
@@ -0,0 +1,239 @@
+package giniapi
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DedupeIndex maps the content hashes of uploaded documents to their IDs.
+// Keys are the hex encoded SHA-256 of the content, prefixed with the user
+// identifier and a slash for BasicAuth uploads ("user123/9f86d0...").
+type DedupeIndex interface {
+	// Get returns the document ID of a key or false if unknown
+	Get(key string) (string, bool, error)
+	// Put stores the document ID of a key
+	Put(key, documentID string) error
+}
+
+// DedupeOptions specify parameters to the UploadOnce function
+type DedupeOptions struct {
+	// Index of uploaded documents
+	Index DedupeIndex
+	// MatchName also returns the first listed document with the FileName of
+	// the upload if the index has no entry. Names do not prove equal content,
+	// use it to recover from a lost index only.
+	MatchName bool
+}
+
+// UploadOnce uploads a document unless the same content was uploaded
+// before. The SHA-256 of the content is looked up in the index and the
+// existing document is returned with the message "document already
+// uploaded". Index entries of deleted documents are replaced by a new upload.
+// Seekable readers are hashed and rewound, other readers are spooled to a
+// temporary file. The MaxSize of the options is enforced while hashing.
+func (api *APIClient) UploadOnce(ctx context.Context, document io.Reader, options UploadOptions, dedupe DedupeOptions) (*Document, APIResponse) {
+	hash := sha256.New()
+
+	tooLarge := func(size int64) bool {
+		return options.MaxSize > 0 && size > options.MaxSize
+	}
+
+	if seeker, ok := document.(io.ReadSeeker); ok {
+		if size := readerSize(seeker); tooLarge(size) {
+			return nil, apiResponse(ErrUploadTooLarge, "", nil, fmt.Errorf("%s: %d > %d bytes", ErrUploadTooLarge, size, options.MaxSize))
+		}
+
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			_, err = io.Copy(hash, seeker)
+		}
+		if err == nil {
+			_, err = seeker.Seek(start, io.SeekStart)
+		}
+		if err != nil {
+			return nil, apiResponse(ErrUploadSource, "", nil, err)
+		}
+	} else {
+		spool, err := ioutil.TempFile("", "giniapi")
+		if err != nil {
+			return nil, apiResponse(ErrUploadSource, "", nil, err)
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+
+		var src io.Reader = document
+		if options.MaxSize > 0 {
+			src = io.LimitReader(document, options.MaxSize+1)
+		}
+
+		size, err := io.Copy(io.MultiWriter(spool, hash), src)
+		if err == nil && tooLarge(size) {
+			return nil, apiResponse(ErrUploadTooLarge, "", nil, fmt.Errorf("%s: more than %d bytes", ErrUploadTooLarge, options.MaxSize))
+		}
+		if err == nil {
+			_, err = spool.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			return nil, apiResponse(ErrUploadSource, "", nil, err)
+		}
+		document = spool
+	}
+
+	key := hex.EncodeToString(hash.Sum(nil))
+	if options.UserIdentifier != "" {
+		key = fmt.Sprintf("%s/%s", options.UserIdentifier, key)
+	}
+
+	if dedupe.Index != nil {
+		id, ok, err := dedupe.Index.Get(key)
+		if err != nil {
+			return nil, apiResponse(ErrDedupeIndex, "", nil, err)
+		}
+		if ok {
+			doc, resp := api.Get(ctx, fmt.Sprintf("%s/documents/%s", api.Config.Endpoints.API, id), options.UserIdentifier)
+			if resp.Error == nil {
+				return doc, apiResponse("document already uploaded", doc.ID, resp.HttpResponse, nil)
+			}
+			if resp.HttpResponse == nil || resp.HttpResponse.StatusCode != http.StatusNotFound {
+				return nil, resp
+			}
+		}
+	}
+
+	if dedupe.MatchName && options.FileName != "" {
+		doc, resp := api.findByName(ctx, options.FileName, options.UserIdentifier)
+		if resp.Error != nil {
+			return nil, resp
+		}
+		if doc != nil {
+			if dedupe.Index != nil {
+				if err := dedupe.Index.Put(key, doc.ID); err != nil {
+					return nil, apiResponse(ErrDedupeIndex, doc.ID, nil, err)
+				}
+			}
+			return doc, apiResponse("document already uploaded", doc.ID, resp.HttpResponse, nil)
+		}
+	}
+
+	doc, resp := api.Upload(ctx, document, options)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	if dedupe.Index != nil {
+		if err := dedupe.Index.Put(key, doc.ID); err != nil {
+			return doc, apiResponse(ErrDedupeIndex, doc.ID, resp.HttpResponse, err)
+		}
+	}
+
+	return doc, resp
+}
+
+// findByName returns the first listed document with the given name or nil
+func (api *APIClient) findByName(ctx context.Context, name, userIdentifier string) (*Document, APIResponse) {
+	const limit = 100
+
+	for offset := 0; ; offset += limit {
+		set, resp := api.List(ctx, ListOptions{Limit: limit, Offset: offset, UserIdentifier: userIdentifier})
+		if resp.Error != nil {
+			return nil, resp
+		}
+
+		for _, doc := range set.Documents {
+			if doc.Name == name {
+				return doc, resp
+			}
+		}
+
+		if len(set.Documents) < limit || offset+limit >= set.TotalCount {
+			return nil, resp
+		}
+	}
+}
+
+// MemoryIndex is a DedupeIndex held in memory, safe for concurrent use
+type MemoryIndex struct {
+	mu  sync.RWMutex
+	ids map[string]string
+}
+
+// NewMemoryIndex returns an empty MemoryIndex
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{ids: map[string]string{}}
+}
+
+// Get returns the document ID of a key
+func (m *MemoryIndex) Get(key string) (string, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.ids[key]
+	return id, ok, nil
+}
+
+// Put stores the document ID of a key
+func (m *MemoryIndex) Put(key, documentID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ids[key] = documentID
+	return nil
+}
+
+// FileIndex is a DedupeIndex persisted to a file, which survives restarts
+// of ingest jobs. Every Put appends a line "key documentID", later lines
+// override earlier ones. It is safe for concurrent use within a process.
+type FileIndex struct {
+	MemoryIndex
+	file *os.File
+}
+
+// OpenFileIndex loads the index from path, creating the file if needed
+func OpenFileIndex(path string) (*FileIndex, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &FileIndex{MemoryIndex: MemoryIndex{ids: map[string]string{}}, file: f}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.LastIndex(line, " "); i > 0 {
+			index.ids[line[:i]] = line[i+1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// Put stores the document ID of a key and appends it to the file
+func (f *FileIndex) Put(key, documentID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := fmt.Fprintf(f.file, "%s %s\n", key, documentID); err != nil {
+		return err
+	}
+	f.ids[key] = documentID
+	return nil
+}
+
+// Close closes the file
+func (f *FileIndex) Close() error {
+	return f.file.Close()
+}
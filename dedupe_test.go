@@ -0,0 +1,118 @@
+package giniapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_UploadOnce(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	content := []byte("%PDF-1.4 invoice")
+	sum := sha256.Sum256(content)
+	key := "user1/" + hex.EncodeToString(sum[:])
+
+	uploads := 0
+	options := UploadOptions{
+		UserIdentifier: "user1",
+		Progress:       func(sent, total int64) { uploads++ },
+	}
+	dedupe := DedupeOptions{Index: NewMemoryIndex()}
+
+	doc, resp := client.UploadOnce(ctx, bytes.NewReader(content), options, dedupe)
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.Message, "document upload completed", "")
+	assertEqual(t, uploads, 1, "")
+
+	id, ok, _ := dedupe.Index.Get(key)
+	assertEqual(t, ok, true, "")
+	assertEqual(t, id, doc.ID, "")
+
+	// readers without Seek are spooled
+	doc, resp = client.UploadOnce(ctx, ioutil.NopCloser(bytes.NewReader(content)), options, dedupe)
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.Message, "document already uploaded", "")
+	assertEqual(t, doc.ID, "626626a0-749f-11e2-bfd6-000000000000", "")
+	assertEqual(t, doc.Owner, "user1", "")
+	assertEqual(t, uploads, 1, "")
+
+	// deleted documents are uploaded again
+	dedupe.Index.Put(key, "deleted")
+	_, resp = client.UploadOnce(ctx, ioutil.NopCloser(bytes.NewReader(content)), options, dedupe)
+
+	assertEqual(t, resp.Message, "document upload completed", "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "16/16", "")
+	assertEqual(t, uploads, 2, "")
+	id, _, _ = dedupe.Index.Get(key)
+	assertEqual(t, id, "626626a0-749f-11e2-bfd6-000000000000", "")
+
+	// the maximum size is enforced without reading the whole document
+	large := bytes.NewReader(make([]byte, 1<<20))
+	options.MaxSize = 1024
+	_, resp = client.UploadOnce(ctx, ioutil.NopCloser(large), options, dedupe)
+
+	assertEqual(t, resp.Message, ErrUploadTooLarge, "")
+	assertEqual(t, large.Len() > 0, true, "")
+	assertEqual(t, uploads, 2, "")
+
+	_, resp = client.UploadOnce(ctx, bytes.NewReader(make([]byte, 2048)), options, dedupe)
+
+	assertEqual(t, resp.Message, ErrUploadTooLarge, "")
+}
+
+func Test_UploadOnceMatchName(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	index := NewMemoryIndex()
+	doc, resp := client.UploadOnce(ctx, bytes.NewReader([]byte("native")), UploadOptions{UserIdentifier: "user1", FileName: "native.pdf"}, DedupeOptions{Index: index, MatchName: true})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.Message, "document already uploaded", "")
+	assertEqual(t, doc.ID, "626626a0-749f-11e2-abc2-000000000000", "")
+	assertEqual(t, len(index.ids), 1, "")
+
+	// unknown names are uploaded
+	_, resp = client.UploadOnce(ctx, bytes.NewReader([]byte("new")), UploadOptions{UserIdentifier: "user1", FileName: "new.pdf"}, DedupeOptions{MatchName: true})
+
+	assertEqual(t, resp.Message, "document upload completed", "")
+}
+
+func Test_FileIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "giniapi")
+	assertEqual(t, err, nil, "")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "index")
+
+	index, err := OpenFileIndex(path)
+	assertEqual(t, err, nil, "")
+
+	_, ok, _ := index.Get("user 1/abc")
+	assertEqual(t, ok, false, "")
+
+	assertEqual(t, index.Put("user 1/abc", "1"), nil, "")
+	assertEqual(t, index.Put("def", "2"), nil, "")
+	assertEqual(t, index.Put("user 1/abc", "3"), nil, "")
+	assertEqual(t, index.Close(), nil, "")
+
+	index, err = OpenFileIndex(path)
+	assertEqual(t, err, nil, "")
+	defer index.Close()
+
+	id, ok, _ := index.Get("user 1/abc")
+	assertEqual(t, ok, true, "")
+	assertEqual(t, id, "3", "")
+
+	id, _, _ = index.Get("def")
+	assertEqual(t, id, "2", "")
+}
@@ -6,7 +6,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
+	// page images are rendered as JPEG or PNG
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
 	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,6 +37,34 @@ type Page struct {
 	PageNumber int               `json:"pageNumber"`
 }
 
+// ImageSizes returns the available renderings of a page ("750x900"),
+// smallest first
+func (p Page) ImageSizes() []string {
+	sizes := make([]string, 0, len(p.Images))
+	for size := range p.Images {
+		sizes = append(sizes, size)
+	}
+
+	area := func(size string) int {
+		parts := strings.SplitN(size, "x", 2)
+		if len(parts) != 2 {
+			return 0
+		}
+		width, _ := strconv.Atoi(parts[0])
+		height, _ := strconv.Atoi(parts[1])
+		return width * height
+	}
+
+	sort.Slice(sizes, func(i, j int) bool {
+		if a, b := area(sizes[i]), area(sizes[j]); a != b {
+			return a < b
+		}
+		return sizes[i] < sizes[j]
+	})
+
+	return sizes
+}
+
 // Links contains the links to a documents resources
 type Links struct {
 	Document    string `json:"document"`
@@ -35,20 +73,157 @@ type Links struct {
 	Processed   string `json:"processed"`
 }
 
+// CompositeDocument references a composite document a partial document is
+// part of
+type CompositeDocument struct {
+	Document string `json:"document"`
+}
+
 // Document contains all informations about a single document
 type Document struct {
 	Timing               `json:"-"`
 	client               *APIClient
-	Owner                string `json:"-"`
-	Links                Links  `json:"_links"`
-	CreationDate         int    `json:"creationDate"`
-	ID                   string `json:"id"`
-	Name                 string `json:"name"`
-	Origin               string `json:"origin"`
-	PageCount            int    `json:"pageCount"`
-	Pages                []Page `json:"pages"`
-	Progress             string `json:"progress"`
-	SourceClassification string `json:"sourceClassification"`
+	Owner                string               `json:"-"`
+	Links                Links                `json:"_links"`
+	CreationDate         time.Time            `json:"creationDate"`
+	ID                   string               `json:"id"`
+	Name                 string               `json:"name"`
+	Origin               Origin               `json:"origin"`
+	PageCount            int                  `json:"pageCount"`
+	Pages                []Page               `json:"pages"`
+	Progress             Progress             `json:"progress"`
+	SourceClassification SourceClassification `json:"sourceClassification"`
+	// DocType is the document type hint given on upload, e.g. "Invoice"
+	DocType string `json:"docType,omitempty"`
+	// ExpirationDate is the date the document will be deleted, zero if unset
+	ExpirationDate time.Time `json:"expirationDate"`
+	// PartialDocuments of a composite document
+	PartialDocuments []PartialDocument `json:"partialDocuments,omitempty"`
+	// CompositeDocuments a partial document is part of
+	CompositeDocuments []CompositeDocument `json:"compositeDocuments,omitempty"`
+	// Metadata holds all fields of the API's document not decoded above
+	Metadata map[string]json.RawMessage `json:"-"`
+}
+
+// documentFields are the JSON names of the decoded fields of Document
+var documentFields = func() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(Document{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// UnmarshalJSON decodes a document. The API reports dates in milliseconds
+// since the epoch, RFC 3339 strings are accepted as well. Unknown fields are
+// kept in Metadata.
+func (d *Document) UnmarshalJSON(data []byte) error {
+	type document Document
+	aux := struct {
+		*document
+		CreationDate   json.RawMessage `json:"creationDate"`
+		ExpirationDate json.RawMessage `json:"expirationDate"`
+	}{document: (*document)(d)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if d.CreationDate, err = parseTimestamp(aux.CreationDate); err != nil {
+		return fmt.Errorf("creationDate: %s", err)
+	}
+	if d.ExpirationDate, err = parseTimestamp(aux.ExpirationDate); err != nil {
+		return fmt.Errorf("expirationDate: %s", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	d.Metadata = nil
+	for name, value := range fields {
+		if !documentFields[name] {
+			if d.Metadata == nil {
+				d.Metadata = map[string]json.RawMessage{}
+			}
+			d.Metadata[name] = value
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes a document like the API with dates in milliseconds
+// since the epoch and the fields of Metadata
+func (d Document) MarshalJSON() ([]byte, error) {
+	type document Document
+	aux := struct {
+		document
+		CreationDate   *int64 `json:"creationDate,omitempty"`
+		ExpirationDate *int64 `json:"expirationDate,omitempty"`
+	}{document: document(d)}
+
+	aux.CreationDate = timestampMillis(d.CreationDate)
+	aux.ExpirationDate = timestampMillis(d.ExpirationDate)
+
+	data, err := json.Marshal(aux)
+	if err != nil || len(d.Metadata) == 0 {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range d.Metadata {
+		if !documentFields[name] {
+			fields[name] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// parseTimestamp decodes milliseconds since the epoch or a RFC 3339 string.
+// Missing and null values return the zero time.
+func parseTimestamp(raw json.RawMessage) (time.Time, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return time.Time{}, nil
+	}
+
+	var millis int64
+	if err := json.Unmarshal(raw, &millis); err == nil {
+		return time.Unix(0, millis*int64(time.Millisecond)), nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %s", ErrDateInvalid, raw)
+	}
+	if millis, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Unix(0, millis*int64(time.Millisecond)), nil
+	}
+	t, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %s", ErrDateInvalid, raw)
+	}
+	return t, nil
+}
+
+// timestampMillis returns t in milliseconds since the epoch or nil for the
+// zero time
+func timestampMillis(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	millis := t.UnixNano() / int64(time.Millisecond)
+	return &millis
 }
 
 // DocumentSet is a list of documents with the total count
@@ -88,7 +263,7 @@ func (d *Document) Poll(ctx context.Context, pause time.Duration) APIResponse {
 				docResponse := CombinedResponse{doc, getResponse}
 
 				// we need to keep polling until we hit an error or finish processing
-				if getResponse.Error != nil || (doc.Progress == "COMPLETED" || doc.Progress == "ERROR") {
+				if getResponse.Error != nil || doc.Progress.IsDone() {
 					docProgress <- docResponse
 					return
 				}
@@ -152,7 +327,7 @@ func (d *Document) Delete(ctx context.Context) APIResponse {
 func (d *Document) GetLayout(ctx context.Context) (*Layout, APIResponse) {
 	var layout Layout
 
-	resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Layout, nil, nil, "")
+	resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Layout, nil, nil, d.Owner)
 
 	if err != nil {
 		return nil, apiResponse(ErrHTTPGetFailed, d.ID, resp, err)
@@ -171,15 +346,44 @@ func (d *Document) GetLayout(ctx context.Context) (*Layout, APIResponse) {
 	return &layout, apiResponse("layout completed", d.ID, resp, err)
 }
 
+// GetLayoutXML streams the XML representation of a documents layout to w
+func (d *Document) GetLayoutXML(ctx context.Context, w io.Writer) APIResponse {
+	headers := map[string]string{
+		"Accept": fmt.Sprintf("application/vnd.gini.%s.layout+xml", d.client.Config.APIVersion),
+	}
+
+	resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Layout, nil, headers, d.Owner)
+
+	if err != nil {
+		return apiResponse(ErrHTTPGetFailed, d.ID, resp, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return apiResponse(ErrDocumentLayout, d.ID, resp, errors.New(ErrDocumentLayout))
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return apiResponse(ErrDocumentLayout, d.ID, resp, err)
+	}
+
+	return apiResponse("layout completed", d.ID, resp, nil)
+}
+
 // GetExtractions returns a documents extractions in a Extractions struct
 func (d *Document) GetExtractions(ctx context.Context, incubator bool) (*Extractions, APIResponse) {
+	return d.GetExtractionsWithOptions(ctx, ExtractionOptions{Incubator: incubator})
+}
+
+// GetExtractionsWithOptions returns a documents extractions in a Extractions
+// struct. The options select the API flavour (stable, incubator or another
+// API version) and allow to trim the result to specific labels.
+func (d *Document) GetExtractionsWithOptions(ctx context.Context, options ExtractionOptions) (*Extractions, APIResponse) {
 	var extractions Extractions
-	var headers map[string]string
 
-	if incubator {
-		headers = map[string]string{
-			"Accept": "application/vnd.gini.incubator+json",
-		}
+	headers := map[string]string{
+		"Accept": options.accept(d.client.Config.APIVersion),
 	}
 
 	resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Extractions, nil, headers, d.Owner)
@@ -198,16 +402,76 @@ func (d *Document) GetExtractions(ctx context.Context, incubator bool) (*Extract
 		return nil, apiResponse("decoding failed", d.ID, resp, err)
 	}
 
+	options.apply(&extractions)
+
 	return &extractions, apiResponse("extractions completed", d.ID, resp, err)
 }
 
-// GetProcessed returns a byte array of the processed (rectified, optimized) document
+// CompareExtractions fetches the stable and the incubator extractions of a
+// document and returns their differences. The Incubator flag of options is
+// ignored, all other options apply to both requests.
+func (d *Document) CompareExtractions(ctx context.Context, options ExtractionOptions) (*ExtractionsDiff, APIResponse) {
+	options.Incubator = false
+	stable, resp := d.GetExtractionsWithOptions(ctx, options)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	options.Incubator = true
+	incubator, resp := d.GetExtractionsWithOptions(ctx, options)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	diff := DiffExtractions(stable, incubator)
+
+	return &diff, apiResponse("extractions comparison completed", d.ID, resp.HttpResponse, nil)
+}
+
+// GetProcessed returns a byte array of the processed (rectified, optimized) document.
+// Use DownloadProcessed to stream large documents.
 func (d *Document) GetProcessed(ctx context.Context) ([]byte, APIResponse) {
+	buf := new(bytes.Buffer)
+
+	_, resp := d.DownloadProcessed(ctx, buf, DownloadOptions{})
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	return buf.Bytes(), resp
+}
+
+// GetPageImage downloads and decodes the rendered image of a page. Size is one
+// of the pages ImageSizes, an empty size selects the largest rendering.
+func (d *Document) GetPageImage(ctx context.Context, pageNumber int, size string) (image.Image, APIResponse) {
+	var page *Page
+	for i := range d.Pages {
+		if d.Pages[i].PageNumber == pageNumber {
+			page = &d.Pages[i]
+			break
+		}
+	}
+
+	if page == nil {
+		return nil, apiResponse(ErrDocumentPageImage, d.ID, nil, fmt.Errorf("%s: no page %d", ErrDocumentPageImage, pageNumber))
+	}
+
+	if size == "" {
+		if sizes := page.ImageSizes(); len(sizes) > 0 {
+			size = sizes[len(sizes)-1]
+		}
+	}
+
+	u, ok := page.Images[size]
+	if !ok {
+		return nil, apiResponse(ErrDocumentPageImage, d.ID, nil, fmt.Errorf("%s: no image of size %q", ErrDocumentPageImage, size))
+	}
+
 	headers := map[string]string{
-		"Accept": "application/octet-stream",
+		"Accept": "image/*",
 	}
 
-	resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Processed, nil, headers, d.Owner)
+	resp, err := d.client.makeAPIRequest(ctx, "GET", u, nil, headers, d.Owner)
 
 	if err != nil {
 		return nil, apiResponse(ErrHTTPGetFailed, d.ID, resp, err)
@@ -216,17 +480,15 @@ func (d *Document) GetProcessed(ctx context.Context) ([]byte, APIResponse) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, apiResponse(ErrDocumentProcessed, d.ID, resp, errors.New(ErrDocumentProcessed))
+		return nil, apiResponse(ErrDocumentPageImage, d.ID, resp, errors.New(ErrDocumentPageImage))
 	}
 
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(resp.Body)
-
+	img, _, err := image.Decode(resp.Body)
 	if err != nil {
-		return nil, apiResponse(ErrDocumentProcessed, d.ID, resp, err)
+		return nil, apiResponse("decoding failed", d.ID, resp, err)
 	}
 
-	return buf.Bytes(), apiResponse("processed completed", d.ID, resp, err)
+	return img, apiResponse("page image completed", d.ID, resp, nil)
 }
 
 // SubmitFeedback submits feedback from map
@@ -254,3 +516,72 @@ func (d *Document) SubmitFeedback(ctx context.Context, feedback map[string]map[s
 
 	return apiResponse("feedback completed", d.ID, resp, err)
 }
+
+// labelFeedback is the body of a label feedback, an empty box is left out
+type labelFeedback struct {
+	Value  string `json:"value"`
+	Entity string `json:"entity,omitempty"`
+	Box    *Box   `json:"box,omitempty"`
+}
+
+// SubmitLabelFeedback submits feedback for a single label. Unlike SubmitFeedback
+// only the given label is sent, so other extractions stay untouched. The box
+// is only sent if set, a value-only correction keeps the stored box.
+func (d *Document) SubmitLabelFeedback(ctx context.Context, label string, extraction Extraction) APIResponse {
+	feedback := labelFeedback{Value: extraction.Value, Entity: extraction.Entity}
+	if extraction.Box != (Box{}) {
+		box := extraction.Box
+		feedback.Box = &box
+	}
+
+	feedbackBody, err := json.Marshal(feedback)
+	if err != nil {
+		return apiResponse("encoding failed", d.ID, nil, err)
+	}
+
+	u := fmt.Sprintf("%s/%s", d.Links.Extractions, url.PathEscape(label))
+
+	resp, err := d.client.makeAPIRequest(ctx, "PUT", u, bytes.NewReader(feedbackBody), nil, d.Owner)
+
+	if err != nil {
+		return apiResponse(ErrHTTPPutFailed, d.ID, resp, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return apiResponse(ErrDocumentFeedback, d.ID, resp, errors.New(ErrDocumentFeedback))
+	}
+
+	return apiResponse("label feedback completed", d.ID, resp, err)
+}
+
+// SubmitChangedFeedback compares feedback against the original extractions and
+// submits only the labels that differ, one request per label. The submitted
+// labels are returned in alphabetical order. Submission stops at the first
+// failing label.
+func (d *Document) SubmitChangedFeedback(ctx context.Context, original *Extractions, feedback map[string]Extraction) ([]string, APIResponse) {
+	var changed []string
+
+	for label, extraction := range feedback {
+		if original != nil {
+			if current, ok := original.Extractions[label]; ok && current.Equal(extraction) {
+				continue
+			}
+		}
+		changed = append(changed, label)
+	}
+
+	sort.Strings(changed)
+
+	var submitted []string
+
+	for _, label := range changed {
+		if resp := d.SubmitLabelFeedback(ctx, label, feedback[label]); resp.Error != nil {
+			return submitted, resp
+		}
+		submitted = append(submitted, label)
+	}
+
+	return submitted, apiResponse("changed feedback completed", d.ID, nil, nil)
+}
@@ -1,7 +1,14 @@
 package giniapi
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -65,6 +72,30 @@ func Test_DocumentGetLayout(t *testing.T) {
 	_, resp := doc.GetLayout(ctx)
 
 	assertEqual(t, resp.Error, nil, "")
+
+	// the owner is passed on with basic auth
+	doc.client = testBasicAuthClient(t)
+	doc.Owner = "user123"
+	layout, resp := doc.GetLayout(ctx)
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, len(layout.Pages), 1, "")
+}
+
+func Test_DocumentGetLayoutXML(t *testing.T) {
+	doc := Document{
+		client: testBasicAuthClient(t),
+		Owner:  "user123",
+		Links: Links{
+			Layout: testHTTPServer.URL + "/test/layout",
+		},
+	}
+
+	var buf bytes.Buffer
+	resp := doc.GetLayoutXML(context.Background(), &buf)
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, buf.String(), `<document><page number="1" sizeX="595.3" sizeY="841.9"/></document>`, "")
 }
 
 func Test_DocumentGetExtractions(t *testing.T) {
@@ -96,6 +127,39 @@ func Test_DocumentGetProcessed(t *testing.T) {
 	assertEqual(t, string(docBytes), "get processed", "")
 }
 
+func Test_PageImageSizes(t *testing.T) {
+	page := Page{
+		Images: map[string]string{
+			"1280x1810": "large",
+			"750x900":   "small",
+		},
+	}
+
+	assertEqual(t, fmt.Sprint(page.ImageSizes()), "[750x900 1280x1810]", "")
+}
+
+func Test_DocumentGetPageImage(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	doc, resp := client.Get(ctx, testHTTPServer.URL+"/test/document/get", "user123")
+	assertEqual(t, resp.Error, nil, "")
+
+	img, resp := doc.GetPageImage(ctx, 1, "")
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, img.Bounds().Dx(), 1280, "largest image expected")
+
+	img, resp = doc.GetPageImage(ctx, 1, "750x900")
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, img.Bounds().Dy(), 900, "")
+
+	_, resp = doc.GetPageImage(ctx, 1, "10x10")
+	assertNotEqual(t, resp.Error, nil, "unknown size")
+
+	_, resp = doc.GetPageImage(ctx, 2, "")
+	assertNotEqual(t, resp.Error, nil, "unknown page")
+}
+
 func Test_DocumentSubmitFeedback(t *testing.T) {
 	doc := Document{
 		client: testOauthClient(t),
@@ -128,3 +192,266 @@ func Test_DocumentSubmitFeedback(t *testing.T) {
 	// multiple labels
 	assertEqual(t, resp.Error, nil, "")
 }
+
+func Test_DocumentSubmitLabelFeedback(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Extractions: testHTTPServer.URL + "/test/feedback",
+		},
+	}
+
+	ctx := context.Background()
+
+	// value-only corrections are sent without a box
+	resp := doc.SubmitLabelFeedback(ctx, "iban", Extraction{Entity: "iban", Value: "DE22222111117777766666"})
+	assertEqual(t, resp.Error, nil, "")
+
+	resp = doc.SubmitLabelFeedback(ctx, "iban", Extraction{Value: "DE22222111117777766666", Box: Box{Page: 1, Left: 80, Top: 701, Width: 100, Height: 5}})
+	assertEqual(t, resp.Error, nil, "")
+
+	resp = doc.SubmitLabelFeedback(ctx, "broken", Extraction{Entity: "iban", Value: "DE22222111117777766666"})
+	assertNotEqual(t, resp.Error, nil, "")
+}
+
+func Test_DocumentSubmitChangedFeedback(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Extractions: testHTTPServer.URL + "/test/feedback",
+		},
+	}
+
+	original := &Extractions{
+		Extractions: map[string]Extraction{
+			"iban": {Entity: "iban", Value: "DE22222111117777766666"},
+			"bic":  {Entity: "bic", Value: "HYVEDEMMXXX"},
+		},
+	}
+
+	feedback := map[string]Extraction{
+		"iban":        {Entity: "iban", Value: "DE22222111117777766666"},
+		"bic":         {Entity: "bic", Value: "HYVEDEMM488"},
+		"amountToPay": {Entity: "amount", Value: "24.99:EUR"},
+	}
+
+	ctx := context.Background()
+
+	submitted, resp := doc.SubmitChangedFeedback(ctx, original, feedback)
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, len(submitted), 2, "")
+	assertEqual(t, submitted[0], "amountToPay", "")
+	assertEqual(t, submitted[1], "bic", "")
+
+	// failing label aborts the batch
+	feedback["broken"] = Extraction{Entity: "text", Value: "x"}
+
+	submitted, resp = doc.SubmitChangedFeedback(ctx, original, feedback)
+
+	assertNotEqual(t, resp.Error, nil, "")
+	assertEqual(t, len(submitted), 2, "")
+}
+
+func Test_DocumentDownloadProcessed(t *testing.T) {
+	doc := Document{
+		client: testBasicAuthClient(t),
+		Owner:  "user123",
+		Links: Links{
+			Processed: testHTTPServer.URL + "/test/processed/interrupted",
+		},
+	}
+
+	sum := sha256.Sum256(testProcessedContent)
+	checksum := hex.EncodeToString(sum[:])
+
+	ctx := context.Background()
+	var buf bytes.Buffer
+	info, resp := doc.DownloadProcessed(ctx, &buf, DownloadOptions{Checksum: checksum, Resume: 1})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, bytes.Equal(buf.Bytes(), testProcessedContent), true, "")
+	assertEqual(t, info.ContentType, "application/pdf", "")
+	assertEqual(t, info.ContentLength, int64(len(testProcessedContent)), "")
+	assertEqual(t, info.Written, int64(len(testProcessedContent)), "")
+	assertEqual(t, info.Checksum, checksum, "")
+	assertEqual(t, info.Resumed, 1, "")
+
+	// without resume the download fails after the first half
+	buf.Reset()
+	info, resp = doc.DownloadProcessed(ctx, &buf, DownloadOptions{})
+	assertNotEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.Message, ErrDownloadInterrupted, "")
+	assertEqual(t, info.Written, int64(len(testProcessedContent)/2), "")
+
+	buf.Reset()
+	_, resp = doc.DownloadProcessed(ctx, &buf, DownloadOptions{Checksum: "abc", Resume: 1})
+	assertEqual(t, resp.Message, ErrChecksumMismatch, "")
+
+	// the document changed in between
+	doc.Links.Processed = testHTTPServer.URL + "/test/processed/changed"
+	buf.Reset()
+	_, resp = doc.DownloadProcessed(ctx, &buf, DownloadOptions{Resume: 1})
+	assertNotEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.Message, ErrDocumentProcessed, "")
+
+	// failed range requests count against the resume requests
+	for _, mode := range []string{"dropped", "unavailable", "resent"} {
+		doc.Links.Processed = testHTTPServer.URL + "/test/processed/" + mode
+		atomic.StoreInt32(&testRangeRequests, 0)
+		buf.Reset()
+		info, resp = doc.DownloadProcessed(ctx, &buf, DownloadOptions{Checksum: checksum, Resume: 2})
+		assertEqual(t, resp.Error, nil, mode)
+		assertEqual(t, bytes.Equal(buf.Bytes(), testProcessedContent), true, mode)
+		assertEqual(t, info.ContentLength, int64(len(testProcessedContent)), mode)
+		assertEqual(t, info.Resumed, 2, mode)
+
+		atomic.StoreInt32(&testRangeRequests, 0)
+		buf.Reset()
+		info, resp = doc.DownloadProcessed(ctx, &buf, DownloadOptions{Resume: 1})
+		assertEqual(t, resp.Message, ErrDownloadInterrupted, mode)
+		assertEqual(t, info.Resumed, 1, mode)
+	}
+}
+
+// testCompositeChange applies a change to the composite document of the test
+// server and returns the partial documents sent to create the new composite
+func testCompositeChange(t *testing.T, change func(ctx context.Context, doc *Document) APIResponse) (*Document, string, APIResponse) {
+	ctx := context.Background()
+	doc, resp := testBasicAuthClient(t).Get(ctx, testHTTPServer.URL+"/test/document/composite", "user1")
+	assertEqual(t, resp.Error, nil, "")
+
+	testLastComposite.Lock()
+	testLastComposite.body = ""
+	testLastComposite.Unlock()
+
+	resp = change(ctx, doc)
+
+	testLastComposite.Lock()
+	defer testLastComposite.Unlock()
+
+	body := strings.Replace(testLastComposite.body, testHTTPServer.URL+"/test/document/partial/", "", -1)
+	return doc, body, resp
+}
+
+func Test_DocumentRotatePartial(t *testing.T) {
+	doc, body, resp := testCompositeChange(t, func(ctx context.Context, doc *Document) APIResponse {
+		return doc.RotatePartial(ctx, 1, 270)
+	})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.Message, "update completed", "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "composite|Invoice|application/vnd.gini.v2.composite+json", "")
+	assertEqual(t, body, `{"partialDocuments":[{"document":"1","rotationDelta":0},{"document":"2","rotationDelta":0}]}`, "")
+	assertEqual(t, doc.PageCount, 2, "")
+	assertEqual(t, doc.Owner, "user1", "")
+
+	_, body, _ = testCompositeChange(t, func(ctx context.Context, doc *Document) APIResponse {
+		return doc.RotatePartial(ctx, 0, -90)
+	})
+	assertEqual(t, body, `{"partialDocuments":[{"document":"1","rotationDelta":270},{"document":"2","rotationDelta":90}]}`, "")
+
+	for _, invalid := range [][2]int{{2, 90}, {-1, 90}, {0, 45}} {
+		_, body, resp = testCompositeChange(t, func(ctx context.Context, doc *Document) APIResponse {
+			return doc.RotatePartial(ctx, invalid[0], invalid[1])
+		})
+		assertEqual(t, resp.Message, ErrCompositeInvalid, "")
+		assertEqual(t, body, "", "")
+	}
+
+	// documents without partials are no composites
+	doc = &Document{client: testBasicAuthClient(t), ID: "1234", PageCount: 1}
+	resp = doc.RotatePartial(context.Background(), 0, 90)
+	assertEqual(t, resp.Message, ErrCompositeInvalid, "")
+}
+
+func Test_DocumentReorderPartials(t *testing.T) {
+	_, body, resp := testCompositeChange(t, func(ctx context.Context, doc *Document) APIResponse {
+		return doc.ReorderPartials(ctx, []int{1, 0})
+	})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, body, `{"partialDocuments":[{"document":"2","rotationDelta":90},{"document":"1","rotationDelta":0}]}`, "")
+
+	for _, invalid := range [][]int{{0}, {0, 0}, {0, 2}, {1, 0, 2}} {
+		_, body, resp = testCompositeChange(t, func(ctx context.Context, doc *Document) APIResponse {
+			return doc.ReorderPartials(ctx, invalid)
+		})
+		assertEqual(t, resp.Message, ErrCompositeInvalid, "")
+		assertEqual(t, body, "", "")
+	}
+}
+
+func Test_DocumentRemovePartial(t *testing.T) {
+	doc, body, resp := testCompositeChange(t, func(ctx context.Context, doc *Document) APIResponse {
+		return doc.RemovePartial(ctx, 0)
+	})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, body, `{"partialDocuments":[{"document":"2","rotationDelta":90}]}`, "")
+	assertEqual(t, doc.ID, "7a1c3d40-749f-11e2-bfd6-000000000000", "")
+
+	_, _, resp = testCompositeChange(t, func(ctx context.Context, doc *Document) APIResponse {
+		return doc.RemovePartial(ctx, 2)
+	})
+	assertEqual(t, resp.Message, ErrCompositeInvalid, "")
+
+	_, _, resp = testCompositeChange(t, func(ctx context.Context, doc *Document) APIResponse {
+		doc.PartialDocuments = doc.PartialDocuments[:1]
+		return doc.RemovePartial(ctx, 0)
+	})
+	assertEqual(t, resp.Message, ErrCompositeInvalid, "")
+}
+
+func Test_DocumentJSON(t *testing.T) {
+	data := []byte(`{
+		"id": "1234",
+		"creationDate": 1360623867402,
+		"expirationDate": "2019-02-11T23:04:27Z",
+		"docType": "Invoice",
+		"progress": "COMPLETED",
+		"compositeDocuments": [{"document": "https://api.gini.net/documents/5678"}],
+		"archived": true,
+		"tags": ["tax", "2018"]
+	}`)
+
+	var doc Document
+	assertEqual(t, json.Unmarshal(data, &doc), nil, "")
+
+	assertEqual(t, doc.CreationDate.Equal(time.Unix(1360623867, 402000000)), true, "")
+	assertEqual(t, doc.ExpirationDate.Equal(time.Date(2019, 2, 11, 23, 4, 27, 0, time.UTC)), true, "")
+	assertEqual(t, doc.DocType, "Invoice", "")
+	assertEqual(t, doc.CompositeDocuments[0].Document, "https://api.gini.net/documents/5678", "")
+	assertEqual(t, len(doc.Metadata), 2, "")
+	assertEqual(t, string(doc.Metadata["archived"]), "true", "")
+	assertEqual(t, string(doc.Metadata["tags"]), `["tax", "2018"]`, "")
+
+	encoded, err := json.Marshal(&doc)
+	assertEqual(t, err, nil, "")
+
+	var fields map[string]interface{}
+	json.Unmarshal(encoded, &fields)
+
+	assertEqual(t, fields["creationDate"], float64(1360623867402), "")
+	assertEqual(t, fields["expirationDate"], float64(1549926267000), "")
+	assertEqual(t, fields["archived"], true, "")
+	assertEqual(t, fields["id"], "1234", "")
+
+	// encoding and decoding keeps all fields
+	var decoded Document
+	assertEqual(t, json.Unmarshal(encoded, &decoded), nil, "")
+	assertEqual(t, decoded.CreationDate.Equal(doc.CreationDate), true, "")
+	assertEqual(t, decoded.ExpirationDate.Equal(doc.ExpirationDate), true, "")
+	assertEqual(t, fmt.Sprint(decoded.Metadata["tags"]), fmt.Sprint(json.RawMessage(`["tax","2018"]`)), "")
+
+	// missing dates are zero and omitted
+	doc = Document{}
+	assertEqual(t, json.Unmarshal([]byte(`{"id": "1", "creationDate": null}`), &doc), nil, "")
+	assertEqual(t, doc.CreationDate.IsZero(), true, "")
+	assertEqual(t, doc.Metadata == nil, true, "")
+
+	encoded, _ = json.Marshal(doc)
+	assertEqual(t, strings.Contains(string(encoded), "Date"), false, "")
+
+	assertNotEqual(t, json.Unmarshal([]byte(`{"creationDate": "yesterday"}`), &doc), nil, "")
+}
@@ -0,0 +1,209 @@
+package giniapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resumeBackoff is the delay before the first resume request, it grows with
+// every further attempt
+const resumeBackoff = 100 * time.Millisecond
+
+// DownloadOptions specify parameters to the DownloadProcessed function
+type DownloadOptions struct {
+	// Checksum is the expected hex encoded SHA-256 of the document. A
+	// mismatch returns ErrChecksumMismatch after all bytes were written.
+	Checksum string
+	// Resume is the number of Range requests made to continue an
+	// interrupted download after the bytes already written
+	Resume int
+}
+
+// DownloadInfo describes a download
+type DownloadInfo struct {
+	ContentType string
+	// ContentLength is the size of the document or -1 if unknown
+	ContentLength int64
+	// Written is the number of bytes written
+	Written int64
+	// Checksum is the hex encoded SHA-256 of the written bytes
+	Checksum string
+	// Resumed is the number of Range requests made
+	Resumed int
+}
+
+// writeRecorder keeps the error of the destination writer to tell it apart
+// from read errors of the response body
+type writeRecorder struct {
+	w   io.Writer
+	err error
+}
+
+func (r *writeRecorder) Write(p []byte) (int, error) {
+	n, err := r.w.Write(p)
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// DownloadProcessed streams the processed (rectified, optimized) document to
+// w without buffering it. Downloads interrupted by a short body, a failed
+// request or a server error after the first byte are continued with up to
+// options.Resume Range requests. Servers ignoring the range resend the whole
+// document, the bytes already written are skipped then. Downloads of a
+// document that changed in between (ETag mismatch) fail.
+func (d *Document) DownloadProcessed(ctx context.Context, w io.Writer, options DownloadOptions) (*DownloadInfo, APIResponse) {
+	info := &DownloadInfo{ContentLength: -1}
+	hash := sha256.New()
+	dst := &writeRecorder{w: io.MultiWriter(w, hash)}
+	var etag string
+
+	// resume waits before the next attempt or reports why there is none
+	resume := func(err error) error {
+		if info.Resumed >= options.Resume || ctx.Err() != nil {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("%s: %s", ErrDownloadInterrupted, err)
+		}
+		info.Resumed++
+
+		timer := time.NewTimer(time.Duration(info.Resumed) * resumeBackoff)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %s", ErrDownloadInterrupted, ctx.Err())
+		case <-timer.C:
+			return nil
+		}
+	}
+
+	for {
+		headers := map[string]string{
+			"Accept": "application/octet-stream",
+		}
+		if info.Written > 0 {
+			headers["Range"] = fmt.Sprintf("bytes=%d-", info.Written)
+			if etag != "" {
+				headers["If-Range"] = etag
+			}
+		}
+
+		resp, err := d.client.makeAPIRequest(ctx, "GET", d.Links.Processed, nil, headers, d.Owner)
+
+		if err != nil {
+			if info.Written == 0 {
+				return info, apiResponse(ErrHTTPGetFailed, d.ID, resp, err)
+			}
+			if err := resume(err); err != nil {
+				return info, apiResponse(ErrDownloadInterrupted, d.ID, resp, err)
+			}
+			continue
+		}
+
+		body, err := info.begin(resp, etag)
+		if err != nil {
+			resp.Body.Close()
+			// server errors on a range request interrupt the download
+			if info.Written == 0 || resp.StatusCode < 500 {
+				return info, apiResponse(ErrDocumentProcessed, d.ID, resp, err)
+			}
+			if err := resume(err); err != nil {
+				return info, apiResponse(ErrDownloadInterrupted, d.ID, resp, err)
+			}
+			continue
+		}
+		if etag == "" {
+			etag = resp.Header.Get("ETag")
+		}
+
+		n, err := io.Copy(dst, body)
+		resp.Body.Close()
+		info.Written += n
+
+		complete := err == nil && (info.ContentLength < 0 || info.Written >= info.ContentLength)
+
+		if !complete {
+			if dst.err != nil {
+				return info, apiResponse(ErrDocumentProcessed, d.ID, resp, dst.err)
+			}
+			if err := resume(err); err != nil {
+				return info, apiResponse(ErrDownloadInterrupted, d.ID, resp, err)
+			}
+			continue
+		}
+
+		info.Checksum = hex.EncodeToString(hash.Sum(nil))
+		if options.Checksum != "" && !strings.EqualFold(options.Checksum, info.Checksum) {
+			return info, apiResponse(ErrChecksumMismatch, d.ID, resp, errors.New(ErrChecksumMismatch))
+		}
+
+		return info, apiResponse("processed completed", d.ID, resp, nil)
+	}
+}
+
+// begin checks the response of a (range) request and returns the body
+// starting at the first byte not yet written
+func (info *DownloadInfo) begin(resp *http.Response, etag string) (io.Reader, error) {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if info.Written > 0 {
+			if changed := resp.Header.Get("ETag"); etag != "" && changed != "" && changed != etag {
+				return nil, fmt.Errorf("%s: document changed during download", ErrDocumentProcessed)
+			}
+			// skipped while copying, so a broken body is resumed
+			return &skipReader{r: resp.Body, skip: info.Written}, nil
+		}
+		info.ContentType = resp.Header.Get("Content-Type")
+		info.ContentLength = resp.ContentLength
+	case http.StatusPartialContent:
+		var start, end int64
+		var total string
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%s", &start, &end, &total); err != nil || start != info.Written {
+			return nil, fmt.Errorf("%s: unexpected content range %q", ErrDocumentProcessed, resp.Header.Get("Content-Range"))
+		}
+		// an unknown complete length ("*") keeps the length known so far
+		if total != "*" {
+			length, err := strconv.ParseInt(total, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: unexpected content range %q", ErrDocumentProcessed, resp.Header.Get("Content-Range"))
+			}
+			info.ContentLength = length
+		}
+	default:
+		return nil, fmt.Errorf("%s: %s", ErrDocumentProcessed, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// skipReader discards the first bytes of r
+type skipReader struct {
+	r    io.Reader
+	skip int64
+}
+
+func (s *skipReader) Read(p []byte) (int, error) {
+	if s.skip > 0 {
+		n, err := io.CopyN(ioutil.Discard, s.r, s.skip)
+		s.skip -= n
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+	}
+	return s.r.Read(p)
+}
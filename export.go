@@ -0,0 +1,341 @@
+package giniapi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// pointsPerInch is the resolution of layout coordinates
+const pointsPerInch = 72.0
+
+// ExportOptions specify parameters to the hOCR and ALTO exporters
+type ExportOptions struct {
+	// DPI is the resolution hOCR bounding boxes are converted to (default 300)
+	DPI float64
+	// Images returns the file name of a page image (optional). hOCR references
+	// the image of every page, ALTO the image of the first page.
+	Images func(page int) string
+}
+
+func (o ExportOptions) dpi() float64 {
+	if o.DPI > 0 {
+		return o.DPI
+	}
+	return 300
+}
+
+func (o ExportOptions) image(page int) string {
+	if o.Images == nil {
+		return ""
+	}
+	return o.Images(page)
+}
+
+// zoneCoordinates returns the union of the paragraphs of a text zone, which
+// carries no coordinates itself
+func zoneCoordinates(zone TextZone) PageCoordinates {
+	if len(zone.Paragraphs) == 0 {
+		return PageCoordinates{}
+	}
+
+	first := zone.Paragraphs[0]
+	left, top, right, bottom := first.L, first.T, first.Right(), first.Bottom()
+	for _, p := range zone.Paragraphs[1:] {
+		left, top = math.Min(left, p.L), math.Min(top, p.T)
+		right, bottom = math.Max(right, p.Right()), math.Max(bottom, p.Bottom())
+	}
+
+	return PageCoordinates{L: left, T: top, W: right - left, H: bottom - top}
+}
+
+// WriteHOCR writes the layout as hOCR 1.2 document. Bounding boxes are
+// converted to pixels of the given resolution.
+func (l *Layout) WriteHOCR(w io.Writer, options ExportOptions) error {
+	scale := options.dpi() / pointsPerInch
+	bbox := func(c PageCoordinates) string {
+		return fmt.Sprintf("bbox %d %d %d %d",
+			int(math.Floor(c.L*scale)), int(math.Floor(c.T*scale)),
+			int(math.Ceil(c.Right()*scale)), int(math.Ceil(c.Bottom()*scale)))
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">` + "\n")
+	b.WriteString(`<html xmlns="http://www.w3.org/1999/xhtml">` + "\n<head>\n<title></title>\n")
+	b.WriteString(`<meta http-equiv="Content-Type" content="text/html;charset=utf-8"/>` + "\n")
+	fmt.Fprintf(&b, `<meta name="ocr-system" content="gini-api-go %s"/>`+"\n", VERSION)
+	b.WriteString(`<meta name="ocr-capabilities" content="ocr_page ocr_carea ocr_par ocr_line ocrx_word ocrp_font"/>` + "\n")
+	b.WriteString("</head>\n<body>\n")
+
+	for _, page := range l.Pages {
+		n := page.Number
+		title := fmt.Sprintf("%s; ppageno %d; scan_res %s %s", bbox(PageCoordinates{W: page.SizeX, H: page.SizeY}), n-1,
+			formatFloat(options.dpi()), formatFloat(options.dpi()))
+		if image := options.image(n); image != "" {
+			title = fmt.Sprintf("image %q; %s", image, title)
+		}
+		fmt.Fprintf(&b, "<div class=\"ocr_page\" id=\"page_%d\" title=\"%s\">\n", n, html.EscapeString(title))
+
+		var block, paragraph, line, word int
+		for _, zone := range page.TextZones {
+			block++
+			fmt.Fprintf(&b, "<div class=\"ocr_carea\" id=\"block_%d_%d\" title=\"%s\">\n", n, block, bbox(zoneCoordinates(zone)))
+
+			for _, p := range zone.Paragraphs {
+				paragraph++
+				fmt.Fprintf(&b, "<p class=\"ocr_par\" id=\"par_%d_%d\" title=\"%s\">\n", n, paragraph, bbox(p.PageCoordinates))
+
+				for _, ln := range p.Lines {
+					line++
+					fmt.Fprintf(&b, "<span class=\"ocr_line\" id=\"line_%d_%d\" title=\"%s\">", n, line, bbox(ln.PageCoordinates))
+
+					for i, wd := range ln.Words {
+						word++
+						if i > 0 {
+							b.WriteString(" ")
+						}
+
+						title := bbox(wd.PageCoordinates)
+						if wd.FontFamily != "" {
+							title += fmt.Sprintf("; x_font %q", wd.FontFamily)
+						}
+						if wd.Fontsize > 0 {
+							title += "; x_fsize " + formatFloat(wd.Fontsize)
+						}
+
+						text := html.EscapeString(wd.Text)
+						if wd.Bold {
+							text = "<strong>" + text + "</strong>"
+						}
+						fmt.Fprintf(&b, "<span class=\"ocrx_word\" id=\"word_%d_%d\" title=\"%s\">%s</span>", n, word, html.EscapeString(title), text)
+					}
+
+					b.WriteString("</span>\n")
+				}
+				b.WriteString("</p>\n")
+			}
+			b.WriteString("</div>\n")
+		}
+		b.WriteString("</div>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ALTO v4 document structure
+type altoDocument struct {
+	XMLName        xml.Name        `xml:"alto"`
+	Namespace      string          `xml:"xmlns,attr"`
+	XSI            string          `xml:"xmlns:xsi,attr"`
+	SchemaLocation string          `xml:"xsi:schemaLocation,attr"`
+	Description    altoDescription `xml:"Description"`
+	Styles         []altoTextStyle `xml:"Styles>TextStyle"`
+	Pages          []altoPage      `xml:"Layout>Page"`
+}
+
+type altoDescription struct {
+	MeasurementUnit string            `xml:"MeasurementUnit"`
+	SourceImage     *altoSourceImage  `xml:"sourceImageInformation,omitempty"`
+	Processing      altoOCRProcessing `xml:"OCRProcessing"`
+}
+
+type altoSourceImage struct {
+	FileName string `xml:"fileName"`
+}
+
+type altoOCRProcessing struct {
+	ID              string `xml:"ID,attr"`
+	SoftwareCreator string `xml:"ocrProcessingStep>processingSoftware>softwareCreator"`
+	SoftwareName    string `xml:"ocrProcessingStep>processingSoftware>softwareName"`
+	SoftwareVersion string `xml:"ocrProcessingStep>processingSoftware>softwareVersion"`
+}
+
+type altoTextStyle struct {
+	ID         string `xml:"ID,attr"`
+	FontFamily string `xml:"FONTFAMILY,attr,omitempty"`
+	FontSize   string `xml:"FONTSIZE,attr"`
+	FontStyle  string `xml:"FONTSTYLE,attr,omitempty"`
+}
+
+type altoBox struct {
+	HPos   string `xml:"HPOS,attr"`
+	VPos   string `xml:"VPOS,attr"`
+	Width  string `xml:"WIDTH,attr"`
+	Height string `xml:"HEIGHT,attr"`
+}
+
+type altoPage struct {
+	ID            string         `xml:"ID,attr"`
+	PhysicalImgNr int            `xml:"PHYSICAL_IMG_NR,attr"`
+	Width         string         `xml:"WIDTH,attr"`
+	Height        string         `xml:"HEIGHT,attr"`
+	PrintSpace    altoPrintSpace `xml:"PrintSpace"`
+}
+
+type altoPrintSpace struct {
+	altoBox
+	Blocks []altoComposedBlock `xml:"ComposedBlock"`
+}
+
+type altoComposedBlock struct {
+	ID string `xml:"ID,attr"`
+	altoBox
+	Blocks []altoTextBlock `xml:"TextBlock"`
+}
+
+type altoTextBlock struct {
+	ID string `xml:"ID,attr"`
+	altoBox
+	Lines []altoTextLine `xml:"TextLine"`
+}
+
+type altoTextLine struct {
+	ID string `xml:"ID,attr"`
+	altoBox
+	Items []interface{}
+}
+
+type altoString struct {
+	XMLName xml.Name `xml:"String"`
+	ID      string   `xml:"ID,attr"`
+	altoBox
+	Content   string `xml:"CONTENT,attr"`
+	StyleRefs string `xml:"STYLEREFS,attr,omitempty"`
+}
+
+type altoSpace struct {
+	XMLName xml.Name `xml:"SP"`
+	HPos    string   `xml:"HPOS,attr"`
+	VPos    string   `xml:"VPOS,attr"`
+	Width   string   `xml:"WIDTH,attr"`
+}
+
+// WriteALTO writes the layout as ALTO v4 XML. Coordinates are given in
+// 1/10 mm (MeasurementUnit mm10), fonts are collected as text styles.
+func (l *Layout) WriteALTO(w io.Writer, options ExportOptions) error {
+	const mm10 = 254 / pointsPerInch
+
+	unit := func(v float64) string {
+		return formatFloat(math.Round(v*mm10*100) / 100)
+	}
+	box := func(c PageCoordinates) altoBox {
+		return altoBox{HPos: unit(c.L), VPos: unit(c.T), Width: unit(c.W), Height: unit(c.H)}
+	}
+
+	doc := altoDocument{
+		Namespace:      "http://www.loc.gov/standards/alto/ns-v4#",
+		XSI:            "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: "http://www.loc.gov/standards/alto/ns-v4# http://www.loc.gov/alto/v4/alto-4-2.xsd",
+		Description: altoDescription{
+			MeasurementUnit: "mm10",
+			Processing: altoOCRProcessing{
+				ID:              "OCR_0",
+				SoftwareCreator: "Gini GmbH",
+				SoftwareName:    "gini-api-go",
+				SoftwareVersion: VERSION,
+			},
+		},
+	}
+	if len(l.Pages) > 0 {
+		if image := options.image(l.Pages[0].Number); image != "" {
+			doc.Description.SourceImage = &altoSourceImage{FileName: image}
+		}
+	}
+
+	styles := map[altoTextStyle]string{}
+	styleRef := func(wd Word) string {
+		if wd.FontFamily == "" && wd.Fontsize == 0 {
+			return ""
+		}
+		style := altoTextStyle{FontFamily: wd.FontFamily, FontSize: formatFloat(wd.Fontsize)}
+		if wd.Bold {
+			style.FontStyle = "bold"
+		}
+		id, ok := styles[style]
+		if !ok {
+			id = fmt.Sprintf("font%d", len(styles))
+			styles[style] = id
+			style.ID = id
+			doc.Styles = append(doc.Styles, style)
+		}
+		return id
+	}
+
+	for _, page := range l.Pages {
+		n := page.Number
+		pageBox := PageCoordinates{W: page.SizeX, H: page.SizeY}
+		p := altoPage{
+			ID:            fmt.Sprintf("page_%d", n),
+			PhysicalImgNr: n,
+			Width:         unit(page.SizeX),
+			Height:        unit(page.SizeY),
+			PrintSpace:    altoPrintSpace{altoBox: box(pageBox)},
+		}
+
+		var block, paragraph, line, word int
+		for _, zone := range page.TextZones {
+			block++
+			composed := altoComposedBlock{ID: fmt.Sprintf("block_%d_%d", n, block), altoBox: box(zoneCoordinates(zone))}
+
+			for _, par := range zone.Paragraphs {
+				paragraph++
+				text := altoTextBlock{ID: fmt.Sprintf("par_%d_%d", n, paragraph), altoBox: box(par.PageCoordinates)}
+
+				for _, ln := range par.Lines {
+					line++
+					textLine := altoTextLine{ID: fmt.Sprintf("line_%d_%d", n, line), altoBox: box(ln.PageCoordinates)}
+
+					for i, wd := range ln.Words {
+						word++
+						if i > 0 {
+							previous := ln.Words[i-1]
+							textLine.Items = append(textLine.Items, altoSpace{
+								HPos:  unit(previous.Right()),
+								VPos:  unit(previous.T),
+								Width: unit(math.Max(0, wd.L-previous.Right())),
+							})
+						}
+						textLine.Items = append(textLine.Items, altoString{
+							ID:        fmt.Sprintf("word_%d_%d", n, word),
+							altoBox:   box(wd.PageCoordinates),
+							Content:   wd.Text,
+							StyleRefs: styleRef(wd),
+						})
+					}
+
+					text.Lines = append(text.Lines, textLine)
+				}
+				composed.Blocks = append(composed.Blocks, text)
+			}
+			p.PrintSpace.Blocks = append(p.PrintSpace.Blocks, composed)
+		}
+
+		doc.Pages = append(doc.Pages, p)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// formatFloat formats v without trailing zeros
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,77 @@
+package giniapi
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_LayoutWriteHOCR(t *testing.T) {
+	var buf bytes.Buffer
+	err := testLayout(t).WriteHOCR(&buf, ExportOptions{
+		DPI:    144,
+		Images: func(page int) string { return fmt.Sprintf("page%d.png", page) },
+	})
+	assertEqual(t, err, nil, "")
+
+	hocr := buf.String()
+	for _, expected := range []string{
+		`<div class="ocr_page" id="page_1" title="image &#34;page1.png&#34;; bbox 0 0 1191 1684; ppageno 0; scan_res 144 144">`,
+		`<div class="ocr_carea" id="block_1_1" title="bbox 108 317 489 391">`,
+		`<span class="ocrx_word" id="word_1_1" title="bbox 108 317 145 338; x_font &#34;Arial-BoldMT&#34;; x_fsize 9.9">Ihre</span>`,
+	} {
+		assertEqual(t, strings.Contains(hocr, expected), true, expected)
+	}
+
+	// the output is well formed XML
+	decoder := xml.NewDecoder(&buf)
+	decoder.Strict = true
+	for {
+		if _, err := decoder.Token(); err != nil {
+			assertEqual(t, err.Error(), "EOF", "")
+			break
+		}
+	}
+}
+
+func Test_LayoutWriteALTO(t *testing.T) {
+	layout := testLayout(t)
+	layout.Pages[0].TextZones[0].Paragraphs[0].Lines[0].Words[0].Bold = true
+
+	var buf bytes.Buffer
+	assertEqual(t, layout.WriteALTO(&buf, ExportOptions{}), nil, "")
+
+	var alto struct {
+		Unit   string `xml:"Description>MeasurementUnit"`
+		Styles []struct {
+			ID    string `xml:"ID,attr"`
+			Style string `xml:"FONTSTYLE,attr"`
+		} `xml:"Styles>TextStyle"`
+		Pages []struct {
+			Width string `xml:"WIDTH,attr"`
+			Lines []struct {
+				Strings []struct {
+					Content string `xml:"CONTENT,attr"`
+					HPos    string `xml:"HPOS,attr"`
+					Style   string `xml:"STYLEREFS,attr"`
+				} `xml:"String"`
+				Spaces []struct{} `xml:"SP"`
+			} `xml:"PrintSpace>ComposedBlock>TextBlock>TextLine"`
+		} `xml:"Layout>Page"`
+	}
+	assertEqual(t, xml.Unmarshal(buf.Bytes(), &alto), nil, "")
+
+	assertEqual(t, alto.Unit, "mm10", "")
+	assertEqual(t, len(alto.Styles), 2, "")
+	assertEqual(t, alto.Styles[0].Style, "bold", "")
+	assertEqual(t, alto.Pages[0].Width, "2100.09", "A4 width in 1/10 mm")
+
+	line := alto.Pages[0].Lines[0]
+	assertEqual(t, len(line.Strings), 3, "")
+	assertEqual(t, len(line.Spaces), 2, "")
+	assertEqual(t, line.Strings[1].Content, "Vorgangsnummer", "")
+	assertEqual(t, line.Strings[0].HPos, "190.5", "")
+	assertEqual(t, line.Strings[1].Style, "font1", "")
+}
@@ -1,5 +1,13 @@
 package giniapi
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // Box struct
 type Box struct {
 	Height float64 `json:"height"`
@@ -9,6 +17,11 @@ type Box struct {
 	Width  float64 `json:"width"`
 }
 
+// Coordinates returns the box as PageCoordinates to compare it with the layout
+func (b Box) Coordinates() PageCoordinates {
+	return PageCoordinates{L: b.Left, T: b.Top, W: b.Width, H: b.Height}
+}
+
 // Extraction struct
 type Extraction struct {
 	Box        `json:"box"`
@@ -17,6 +30,12 @@ type Extraction struct {
 	Value      string `json:"value,omitempty"`
 }
 
+// Equal reports whether two extractions carry the same value, entity and box.
+// The candidates reference is ignored.
+func (e Extraction) Equal(other Extraction) bool {
+	return e.Value == other.Value && e.Entity == other.Entity && e.Box == other.Box
+}
+
 // Document extractions struct
 type Extractions struct {
 	Candidates  map[string][]Extraction `json:"candidates"`
@@ -30,3 +49,237 @@ func (e *Extractions) GetValue(key string) string {
 	}
 	return ""
 }
+
+// AddCandidate adds candidate to the candidates of label unless the
+// extraction or a candidate has the same value. If the label has no
+// extraction yet, candidate becomes the extraction. An extraction without
+// candidates is linked to the new candidates list. The candidates list of an
+// extraction may be shared by several labels (e.g. "amounts"), the candidate
+// is a candidate of all of them then. It reports whether the candidate was
+// added.
+func (e *Extractions) AddCandidate(label string, candidate Extraction) bool {
+	extraction, ok := e.Extractions[label]
+	if ok && extraction.Value == candidate.Value {
+		return false
+	}
+
+	key := extraction.Candidates
+	if key == "" {
+		key = label
+	}
+
+	for _, existing := range e.Candidates[key] {
+		if existing.Value == candidate.Value {
+			return false
+		}
+	}
+
+	if e.Candidates == nil {
+		e.Candidates = map[string][]Extraction{}
+	}
+	e.Candidates[key] = append(e.Candidates[key], candidate)
+
+	if e.Extractions == nil {
+		e.Extractions = map[string]Extraction{}
+	}
+
+	if !ok {
+		candidate.Candidates = key
+		e.Extractions[label] = candidate
+	} else if extraction.Candidates == "" {
+		extraction.Candidates = key
+		e.Extractions[label] = extraction
+	}
+
+	return true
+}
+
+// Amount is a monetary value as used by the amount entity ("24.99:EUR")
+type Amount struct {
+	// Cents holds the value in the currency's minor unit
+	Cents    int64
+	Currency string
+}
+
+// Decimal returns the value with two decimal places ("24.99")
+func (a Amount) Decimal() string {
+	sign, cents := "", a.Cents
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// String returns the amount in Gini's format ("24.99:EUR")
+func (a Amount) String() string {
+	return fmt.Sprintf("%s:%s", a.Decimal(), a.Currency)
+}
+
+// ParseAmount parses an amount value as returned by Gini. Values with more
+// than two decimal places are rounded to cents.
+func ParseAmount(value string) (Amount, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return Amount{}, errors.New(ErrAmountInvalid)
+	}
+
+	number, negative := parts[0], false
+	if strings.HasPrefix(number, "-") {
+		number, negative = number[1:], true
+	}
+
+	units, fraction := number, ""
+	if i := strings.Index(number, "."); i >= 0 {
+		units, fraction = number[:i], number[i+1:]
+	}
+
+	if units == "" || strings.Trim(units+fraction, "0123456789") != "" {
+		return Amount{}, errors.New(ErrAmountInvalid)
+	}
+
+	// round half up on the third decimal place
+	roundUp := len(fraction) > 2 && fraction[2] >= '5'
+	fraction = (fraction + "00")[:2]
+
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return Amount{}, errors.New(ErrAmountInvalid)
+	}
+
+	if roundUp {
+		cents++
+	}
+	if negative {
+		cents = -cents
+	}
+
+	return Amount{Cents: cents, Currency: parts[1]}, nil
+}
+
+// ExtractionOptions specify parameters to the GetExtractionsWithOptions function
+type ExtractionOptions struct {
+	// Incubator requests the incubator extractions, which include labels that
+	// are not yet part of the stable API
+	Incubator bool
+	// APIVersion overrides the version from Config (ignored for incubator)
+	APIVersion string
+	// OmitCandidates drops all candidates from the result
+	OmitCandidates bool
+	// Labels limits the result to the given labels (empty means all)
+	Labels []string
+}
+
+// accept returns the Accept header matching the options
+func (o ExtractionOptions) accept(defaultVersion string) string {
+	if o.Incubator {
+		return "application/vnd.gini.incubator+json"
+	}
+
+	version := defaultVersion
+	if o.APIVersion != "" {
+		version = o.APIVersion
+	}
+
+	return fmt.Sprintf("application/vnd.gini.%s+json", version)
+}
+
+// apply trims the extractions according to the label filter and candidates
+// setting. Candidates are only kept if referenced by a remaining extraction.
+func (o ExtractionOptions) apply(e *Extractions) {
+	if len(o.Labels) > 0 {
+		keep := make(map[string]bool, len(o.Labels))
+		for _, label := range o.Labels {
+			keep[label] = true
+		}
+
+		referenced := map[string]bool{}
+		for label, extraction := range e.Extractions {
+			if !keep[label] {
+				delete(e.Extractions, label)
+				continue
+			}
+			referenced[extraction.Candidates] = true
+		}
+
+		for name := range e.Candidates {
+			if !referenced[name] {
+				delete(e.Candidates, name)
+			}
+		}
+	}
+
+	if o.OmitCandidates {
+		e.Candidates = nil
+		for label, extraction := range e.Extractions {
+			extraction.Candidates = ""
+			e.Extractions[label] = extraction
+		}
+	}
+}
+
+// ExtractionChange holds both sides of a label that exists in two sets of
+// extractions with different content
+type ExtractionChange struct {
+	Old Extraction
+	New Extraction
+}
+
+// ExtractionsDiff describes the differences between two sets of extractions,
+// e.g. stable and incubator
+type ExtractionsDiff struct {
+	// Added labels only exist in the new extractions
+	Added map[string]Extraction
+	// Removed labels only exist in the old extractions
+	Removed map[string]Extraction
+	// Changed labels exist in both but differ in value, entity or box
+	Changed map[string]ExtractionChange
+	// EntityChanged lists the changed labels whose entity (schema) differs
+	EntityChanged []string
+}
+
+// Empty reports whether both sets of extractions are equal
+func (d ExtractionsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffExtractions compares two sets of extractions label by label
+func DiffExtractions(from, to *Extractions) ExtractionsDiff {
+	diff := ExtractionsDiff{
+		Added:   map[string]Extraction{},
+		Removed: map[string]Extraction{},
+		Changed: map[string]ExtractionChange{},
+	}
+
+	var oldExtractions, newExtractions map[string]Extraction
+	if from != nil {
+		oldExtractions = from.Extractions
+	}
+	if to != nil {
+		newExtractions = to.Extractions
+	}
+
+	for label, o := range oldExtractions {
+		n, ok := newExtractions[label]
+		if !ok {
+			diff.Removed[label] = o
+			continue
+		}
+
+		if !o.Equal(n) {
+			diff.Changed[label] = ExtractionChange{Old: o, New: n}
+			if o.Entity != n.Entity {
+				diff.EntityChanged = append(diff.EntityChanged, label)
+			}
+		}
+	}
+
+	for label, n := range newExtractions {
+		if _, ok := oldExtractions[label]; !ok {
+			diff.Added[label] = n
+		}
+	}
+
+	sort.Strings(diff.EntityChanged)
+
+	return diff
+}
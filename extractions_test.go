@@ -19,3 +19,130 @@ func Test_ExtractionsGetValue(t *testing.T) {
 	assertEqual(t, extractions.GetValue("amountToPay"), "24.99:EUR", "")
 	assertEqual(t, extractions.GetValue("unknown"), "", "")
 }
+
+func Test_ExtractionsAddCandidate(t *testing.T) {
+	e := Extractions{
+		Candidates: map[string][]Extraction{
+			"ibans": {{Value: "DE02120300000000202051", Entity: "iban"}},
+		},
+		Extractions: map[string]Extraction{
+			"iban": {Value: "DE02120300000000202051", Entity: "iban", Candidates: "ibans"},
+		},
+	}
+
+	assertEqual(t, e.AddCandidate("iban", Extraction{Value: "DE02120300000000202051"}), false, "duplicate value")
+	assertEqual(t, e.AddCandidate("iban", Extraction{Value: "DE89370400440532013000"}), true, "")
+	assertEqual(t, len(e.Candidates["ibans"]), 2, "")
+
+	assertEqual(t, e.AddCandidate("bic", Extraction{Value: "BYLADEM1001", Box: Box{Page: 2}}), true, "")
+	assertEqual(t, e.GetValue("bic"), "BYLADEM1001", "missing extraction is created")
+	assertEqual(t, e.Extractions["bic"].Candidates, "bic", "")
+	assertEqual(t, e.Extractions["bic"].Page, 2, "")
+	assertEqual(t, len(e.Candidates["bic"]), 1, "")
+
+	// extractions without candidates are linked to the new candidates
+	e.Extractions["amountToPay"] = Extraction{Value: "24.99:EUR", Entity: "amount"}
+	assertEqual(t, e.AddCandidate("amountToPay", Extraction{Value: "12.00:EUR", Entity: "amount"}), true, "")
+	assertEqual(t, e.Extractions["amountToPay"].Candidates, "amountToPay", "")
+	assertEqual(t, e.Extractions["amountToPay"].Value, "24.99:EUR", "")
+	assertEqual(t, e.Candidates[e.Extractions["amountToPay"].Candidates][0].Value, "12.00:EUR", "")
+
+	var empty Extractions
+	assertEqual(t, empty.AddCandidate("iban", Extraction{Value: "DE02120300000000202051"}), true, "")
+}
+
+func Test_ExtractionOptions(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Extractions: testHTTPServer.URL + "/test/extractions",
+		},
+	}
+
+	ctx := context.Background()
+
+	extractions, resp := doc.GetExtractionsWithOptions(ctx, ExtractionOptions{Incubator: true})
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, extractions.GetValue("paymentDueDate"), "2018-12-24", "")
+
+	extractions, _ = doc.GetExtractionsWithOptions(ctx, ExtractionOptions{OmitCandidates: true})
+	assertEqual(t, len(extractions.Candidates), 0, "")
+	assertEqual(t, extractions.Extractions["amountToPay"].Candidates, "", "")
+
+	extractions, _ = doc.GetExtractionsWithOptions(ctx, ExtractionOptions{Labels: []string{"iban"}})
+	assertEqual(t, len(extractions.Extractions), 0, "")
+	assertEqual(t, len(extractions.Candidates), 0, "")
+
+	extractions, _ = doc.GetExtractionsWithOptions(ctx, ExtractionOptions{Labels: []string{"amountToPay"}})
+	assertEqual(t, len(extractions.Extractions), 1, "")
+	assertEqual(t, len(extractions.Candidates["amounts"]), 2, "")
+
+	assertEqual(t, ExtractionOptions{}.accept("v1"), "application/vnd.gini.v1+json", "")
+	assertEqual(t, ExtractionOptions{APIVersion: "v2"}.accept("v1"), "application/vnd.gini.v2+json", "")
+}
+
+func Test_DocumentCompareExtractions(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Extractions: testHTTPServer.URL + "/test/extractions",
+		},
+	}
+
+	ctx := context.Background()
+
+	diff, resp := doc.CompareExtractions(ctx, ExtractionOptions{})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, diff.Empty(), false, "")
+	assertEqual(t, len(diff.Added), 1, "")
+	assertEqual(t, diff.Added["paymentDueDate"].Value, "2018-12-24", "")
+	assertEqual(t, len(diff.Removed), 0, "")
+	assertEqual(t, len(diff.Changed), 0, "")
+}
+
+func Test_DiffExtractions(t *testing.T) {
+	stable := &Extractions{
+		Extractions: map[string]Extraction{
+			"iban":        {Entity: "iban", Value: "DE22222111117777766666"},
+			"docType":     {Entity: "text", Value: "Invoice"},
+			"amountToPay": {Entity: "amount", Value: "24.99:EUR"},
+		},
+	}
+	incubator := &Extractions{
+		Extractions: map[string]Extraction{
+			"iban":    {Entity: "iban", Value: "DE22222111117777766666"},
+			"docType": {Entity: "doctype", Value: "Invoice"},
+		},
+	}
+
+	diff := DiffExtractions(stable, incubator)
+
+	assertEqual(t, len(diff.Removed), 1, "")
+	assertEqual(t, len(diff.Changed), 1, "")
+	assertEqual(t, diff.Changed["docType"].New.Entity, "doctype", "")
+	assertEqual(t, len(diff.EntityChanged), 1, "")
+	assertEqual(t, DiffExtractions(stable, stable).Empty(), true, "")
+}
+
+func Test_ParseAmount(t *testing.T) {
+	amount, err := ParseAmount("24.99:EUR")
+	assertEqual(t, err, nil, "")
+	assertEqual(t, amount.Cents, int64(2499), "")
+	assertEqual(t, amount.Currency, "EUR", "")
+
+	amount, _ = ParseAmount("21.0:EUR")
+	assertEqual(t, amount.String(), "21.00:EUR", "")
+
+	amount, _ = ParseAmount("1.005:EUR")
+	assertEqual(t, amount.Decimal(), "1.01", "")
+
+	amount, _ = ParseAmount("-3.5:USD")
+	assertEqual(t, amount.String(), "-3.50:USD", "")
+
+	_, err = ParseAmount("24.99")
+	assertNotEqual(t, err, nil, "")
+
+	_, err = ParseAmount("24,99:EUR")
+	assertNotEqual(t, err, nil, "")
+}
@@ -40,6 +40,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"reflect"
 	"time"
 )
@@ -53,6 +54,12 @@ const (
 	ErrOauthCredentials       = "failed to obtain token with username/password"
 	ErrOauthParametersMissing = "oauth2 authentication requires AuthCode or Username + Password"
 	ErrUploadFailed           = "failed to upload document"
+	ErrUploadTooLarge         = "document exceeds the maximum upload size"
+	ErrUploadSource           = "failed to fetch document to upload"
+	ErrCompositeInvalid       = "invalid composite document"
+	ErrDedupeIndex            = "failed to access dedupe index"
+	ErrListOptions            = "invalid list options"
+	ErrRetentionFailed        = "failed to delete documents of retention policy"
 	ErrDocumentGet            = "failed to GET document object"
 	ErrDocumentParse          = "failed to parse document json"
 	ErrDocumentRead           = "failed to read document body"
@@ -64,10 +71,17 @@ const (
 	ErrDocumentExtractions    = "failed to retrieve extractions"
 	ErrDocumentProcessed      = "failed to retrieve processed document"
 	ErrDocumentFeedback       = "failed to submit feedback"
+	ErrDocumentPageImage      = "failed to retrieve page image"
+	ErrDownloadInterrupted    = "download of processed document interrupted"
+	ErrChecksumMismatch       = "checksum of processed document does not match"
 	ErrHTTPPostFailed         = "failed to complete POST request"
 	ErrHTTPGetFailed          = "failed to complete GET request"
 	ErrHTTPDeleteFailed       = "failed to complete DELETE request"
 	ErrHTTPPutFailed          = "failed to complete PUT request"
+	ErrAmountInvalid          = "invalid amount"
+	ErrDateInvalid            = "invalid date"
+	ErrIBANInvalid            = "invalid IBAN"
+	ErrBICInvalid             = "invalid BIC"
 )
 
 // Config to setup Gini API connection
@@ -153,6 +167,13 @@ type UploadOptions struct {
 	FileName       string
 	DocType        string
 	UserIdentifier string
+	// ContentType of the document, e.g. "application/pdf"
+	ContentType string
+	// Progress is called with the bytes sent so far and the total size
+	// (-1 if unknown)
+	Progress func(sent, total int64)
+	// MaxSize rejects documents larger than MaxSize bytes (0 for no limit)
+	MaxSize int64
 }
 
 // ListOptions specify parameters to the List function
@@ -160,6 +181,26 @@ type ListOptions struct {
 	Limit          int
 	Offset         int
 	UserIdentifier string
+	// Prefetch requests the next page concurrently while the current page
+	// is consumed (ListAll and DocumentIterator only)
+	Prefetch bool
+	// Progress, SourceClassification and Origin keep documents matching any
+	// of the given values
+	Progress             []Progress
+	SourceClassification []SourceClassification
+	Origin               []Origin
+	// CreatedAfter and CreatedBefore limit the creation date, zero values
+	// do not limit. Documents without creation date are left out if either
+	// is set.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Name keeps documents whose name matches the shell pattern (see
+	// path.Match), e.g. "*.pdf"
+	Name string
+	// SortBy orders the documents by SortByCreationDate, SortByName or
+	// SortByPageCount instead of the order of the API (newest first)
+	SortBy     string
+	Descending bool
 }
 
 // APIClient is the main interface for the user
@@ -192,13 +233,45 @@ func NewClient(config *Config) (*APIClient, error) {
 }
 
 // Upload a document from a given io.Reader object (document). Additional options can be
-// passed with a instance of UploadOptions. FileName and DocType are optional and can be empty.
+// passed with a instance of UploadOptions. FileName, DocType and ContentType are optional and can be empty.
 // UserIdentifier is required if Authentication method is "basic_auth".
 // Upload time is measured and stored in Timing struct (part of Document).
+// The Content-Length is sent for seekable readers and readers with a Len
+// method (bytes.Reader, strings.Reader, ...), other readers are streamed.
 func (api *APIClient) Upload(ctx context.Context, document io.Reader, options UploadOptions) (*Document, APIResponse) {
+	body := newUploadReader(document, options)
+	if options.MaxSize > 0 && body.total > options.MaxSize {
+		return nil, apiResponse(ErrUploadTooLarge, "", nil, fmt.Errorf("%s: %d > %d bytes", ErrUploadTooLarge, body.total, options.MaxSize))
+	}
+
+	params := url.Values{}
+	if options.FileName != "" {
+		params.Set("filename", options.FileName)
+	}
+	if options.DocType != "" {
+		params.Set("doctype", options.DocType)
+	}
+
+	u := fmt.Sprintf("%s/documents", api.Config.Endpoints.API)
+	if len(params) > 0 {
+		u = fmt.Sprintf("%s?%s", u, params.Encode())
+	}
+
+	var headers map[string]string
+	if options.ContentType != "" {
+		headers = map[string]string{"Content-Type": options.ContentType}
+	}
+
 	start := time.Now()
 
-	resp, err := api.makeAPIRequest(ctx, "POST", fmt.Sprintf("%s/documents", api.Config.Endpoints.API), document, nil, options.UserIdentifier)
+	resp, err := api.makeAPIRequest(ctx, "POST", u, body, headers, options.UserIdentifier)
+
+	if body.tooLarge {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, apiResponse(ErrUploadTooLarge, "", resp, fmt.Errorf("%s: more than %d bytes", ErrUploadTooLarge, options.MaxSize))
+	}
 
 	if err != nil {
 		return nil, apiResponse(ErrHTTPPostFailed, "", resp, err)
@@ -215,7 +288,7 @@ func (api *APIClient) Upload(ctx context.Context, document io.Reader, options Up
 	// Fetch the document
 	doc, response := api.Get(ctx, resp.Header.Get("Location"), options.UserIdentifier)
 
-	if err != nil {
+	if response.Error != nil {
 		return nil, response
 	}
 
@@ -256,8 +329,27 @@ func (api *APIClient) Get(ctx context.Context, url, userIdentifier string) (*Doc
 	return &doc, apiResponse("document fetch completed", doc.ID, resp, err)
 }
 
-// List returns DocumentSet
+// List returns DocumentSet. Filters and sort order of the options are
+// applied to the requested page only, TotalCount is the number of all
+// documents. Use ListAll or Iterate to filter and sort all documents.
 func (api *APIClient) List(ctx context.Context, options ListOptions) (*DocumentSet, APIResponse) {
+	if err := options.validate(); err != nil {
+		return nil, apiResponse(ErrListOptions, "", nil, err)
+	}
+
+	docs, resp := api.list(ctx, options)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	docs.Documents = options.filter(docs.Documents)
+	options.sort(docs.Documents)
+
+	return docs, resp
+}
+
+// list returns a page of the listing without filters
+func (api *APIClient) list(ctx context.Context, options ListOptions) (*DocumentSet, APIResponse) {
 	params := map[string]interface{}{
 		"limit":  options.Limit,
 		"offset": options.Offset,
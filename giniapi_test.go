@@ -4,8 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_ConfigVerify(t *testing.T) {
@@ -87,6 +93,153 @@ func Test_DocumentUpload(t *testing.T) {
 	assertEqual(t, err, nil, "")
 	assertEqual(t, resp.Error, nil, "")
 	assertEqual(t, document.ID, "626626a0-749f-11e2-bfd6-000000000000", "")
+
+	// the uploaded document can not be fetched
+	document, resp = client.Upload(ctx, bytes.NewReader([]byte("test")), UploadOptions{UserIdentifier: "user1", FileName: "missing.pdf"})
+
+	assertEqual(t, resp.Message, ErrDocumentGet, "")
+	assertEqual(t, document == nil, true, "")
+}
+
+func Test_DocumentUploadProgress(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	content := bytes.Repeat([]byte("x"), 100000)
+
+	var sent, total []int64
+	progress := func(s, t int64) {
+		sent = append(sent, s)
+		total = append(total, t)
+	}
+
+	// sized readers send their Content-Length
+	_, resp := client.Upload(ctx, bytes.NewReader(content), UploadOptions{UserIdentifier: "user1", Progress: progress})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "100000/100000", "")
+	assertEqual(t, sent[len(sent)-1], int64(100000), "")
+	assertEqual(t, total[0], int64(100000), "")
+
+	// readers of unknown size are streamed
+	sent, total = nil, nil
+	_, resp = client.Upload(ctx, ioutil.NopCloser(bytes.NewReader(content)), UploadOptions{UserIdentifier: "user1", Progress: progress})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "-1/100000", "")
+	assertEqual(t, sent[len(sent)-1], int64(100000), "")
+	assertEqual(t, total[0], int64(-1), "")
+
+	// empty documents are sent with a Content-Length of 0
+	_, resp = client.Upload(ctx, bytes.NewReader(nil), UploadOptions{UserIdentifier: "user1"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "0/0", "")
+}
+
+func Test_DocumentUploadMaxSize(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	content := bytes.Repeat([]byte("x"), 100000)
+
+	// known sizes fail before sending
+	sent := false
+	_, resp := client.Upload(ctx, bytes.NewReader(content), UploadOptions{
+		UserIdentifier: "user1",
+		MaxSize:        1000,
+		Progress:       func(int64, int64) { sent = true },
+	})
+
+	assertEqual(t, resp.Message, ErrUploadTooLarge, "")
+	assertEqual(t, sent, false, "")
+
+	// unknown sizes fail while sending
+	_, resp = client.Upload(ctx, ioutil.NopCloser(bytes.NewReader(content)), UploadOptions{UserIdentifier: "user1", MaxSize: 1000})
+
+	assertEqual(t, resp.Message, ErrUploadTooLarge, "")
+
+	_, resp = client.Upload(ctx, bytes.NewReader(content), UploadOptions{UserIdentifier: "user1", MaxSize: 100000})
+
+	assertEqual(t, resp.Error, nil, "")
+}
+
+func Test_DocumentUploadFile(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "giniapi")
+	assertEqual(t, err, nil, "")
+	defer os.RemoveAll(dir)
+
+	pdf := filepath.Join(dir, "invoice.pdf")
+	ioutil.WriteFile(pdf, []byte("%PDF-1.4 test"), 0600)
+	scan := filepath.Join(dir, "scan")
+	ioutil.WriteFile(scan, []byte("\x89PNG\r\n\x1a\n0000"), 0600)
+
+	_, resp := client.UploadFile(ctx, pdf, UploadOptions{UserIdentifier: "user1", DocType: "Invoice"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "invoice.pdf|Invoice|application/pdf", "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "13/13", "")
+
+	// without extension the type is detected from the content
+	_, resp = client.UploadFile(ctx, scan, UploadOptions{UserIdentifier: "user1", FileName: "page1.png"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "page1.png||image/png", "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "12/12", "")
+
+	_, resp = client.UploadFile(ctx, filepath.Join(dir, "missing.pdf"), UploadOptions{UserIdentifier: "user1"})
+
+	assertEqual(t, resp.Message, ErrUploadSource, "")
+}
+
+func Test_DocumentUploadBytes(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	_, resp := client.UploadBytes(ctx, []byte("%PDF-1.4 test"), UploadOptions{UserIdentifier: "user1"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "||application/pdf", "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "13/13", "")
+}
+
+func Test_DocumentUploadFromURL(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	_, resp := client.UploadFromURL(ctx, testHTTPServer.URL+"/test/source/invoice.pdf", UploadOptions{UserIdentifier: "user1"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "invoice.pdf||application/pdf", "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "13/13", "")
+
+	_, resp = client.UploadFromURL(ctx, testHTTPServer.URL+"/test/source/attachment", UploadOptions{UserIdentifier: "user1", DocType: "Invoice"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "invoice 4711.pdf|Invoice|application/pdf", "")
+
+	_, resp = client.UploadFromURL(ctx, testHTTPServer.URL+"/test/source/missing.pdf", UploadOptions{UserIdentifier: "user1"})
+
+	assertEqual(t, resp.Message, ErrUploadSource, "")
+	assertNotEqual(t, resp.Error, nil, "")
+}
+
+func Test_readerSize(t *testing.T) {
+	reader := strings.NewReader("0123456789")
+	reader.Seek(4, io.SeekStart)
+
+	assertEqual(t, readerSize(bytes.NewBufferString("abc")), int64(3), "")
+	assertEqual(t, readerSize(reader), int64(6), "")
+	assertEqual(t, readerSize(io.NewSectionReader(reader, 2, 5)), int64(5), "")
+	assertEqual(t, readerSize(ioutil.NopCloser(reader)), int64(-1), "")
+
+	// sized streams report the bytes left
+	sized := &sizedReader{Reader: strings.NewReader("abcdef"), size: 6}
+	sized.Read(make([]byte, 4))
+	assertEqual(t, readerSize(sized), int64(2), "")
 }
 
 func Test_DocumentGet(t *testing.T) {
@@ -108,7 +261,7 @@ func Test_DocumentGet(t *testing.T) {
 	assertEqual(t, err, nil, "")
 	assertEqual(t, resp.Error, nil, "")
 	assertEqual(t, document.Owner, "user1", "")
-	assertEqual(t, document.Progress, "COMPLETED", "")
+	assertEqual(t, document.Progress, ProgressCompleted, "")
 }
 
 func Test_DocumentList(t *testing.T) {
@@ -132,3 +285,99 @@ func Test_DocumentList(t *testing.T) {
 	assertEqual(t, documents.TotalCount, 2, "")
 	assertEqual(t, documents.Documents[1].String(), "626626a0-749f-11e2-abc2-000000000000", "")
 }
+
+func Test_DocumentListFilters(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	documents, resp := client.List(ctx, ListOptions{UserIdentifier: "user1", SourceClassification: []SourceClassification{SourceNative, SourceText}})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, documents.TotalCount, 2, "")
+	assertEqual(t, len(documents.Documents), 1, "")
+	assertEqual(t, documents.Documents[0].Name, "native.pdf", "")
+
+	documents, _ = client.List(ctx, ListOptions{UserIdentifier: "user1", Progress: []Progress{ProgressPending}})
+	assertEqual(t, len(documents.Documents), 0, "")
+
+	documents, _ = client.List(ctx, ListOptions{UserIdentifier: "user1", SortBy: SortByCreationDate, Descending: true})
+	assertEqual(t, documents.Documents[0].Name, "native.pdf", "")
+
+	documents, _ = client.List(ctx, ListOptions{UserIdentifier: "user1", Name: "scanned.*", Origin: []Origin{OriginUpload}})
+	assertEqual(t, len(documents.Documents), 1, "")
+	assertEqual(t, documents.Documents[0].Name, "scanned.jpg", "")
+
+	documents, _ = client.List(ctx, ListOptions{UserIdentifier: "user1", CreatedAfter: time.Unix(1360624000, 0)})
+	assertEqual(t, len(documents.Documents), 1, "")
+	assertEqual(t, documents.Documents[0].Name, "native.pdf", "")
+
+	_, resp = client.List(ctx, ListOptions{UserIdentifier: "user1", Name: "[a-"})
+	assertEqual(t, resp.Message, ErrListOptions, "")
+}
+
+func Test_DocumentUploadPartial(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+
+	// the type is detected and the size kept
+	_, resp := client.UploadPartial(ctx, bytes.NewReader(png), UploadOptions{UserIdentifier: "user1"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "||application/vnd.gini.v2.partial+png", "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Length"), "12/12", "")
+
+	_, resp = client.UploadPartial(ctx, bytes.NewReader([]byte("%PDF-1.4")), UploadOptions{UserIdentifier: "user1", ContentType: "application/pdf"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "||application/vnd.gini.v2.partial+pdf", "")
+
+	// partial content types are sent unchanged
+	_, resp = client.UploadPartial(ctx, bytes.NewReader([]byte("%PDF-1.4")), UploadOptions{UserIdentifier: "user1", ContentType: "application/vnd.gini.v2.partial+pdf"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "||application/vnd.gini.v2.partial+pdf", "")
+
+	_, resp = client.UploadPartial(ctx, bytes.NewReader(png), UploadOptions{UserIdentifier: "user1", ContentType: "image/"})
+
+	assertEqual(t, resp.Message, ErrUploadFailed, "")
+
+	// other content is not uploaded
+	_, resp = client.UploadPartial(ctx, bytes.NewReader([]byte{0, 1, 2, 3}), UploadOptions{UserIdentifier: "user1"})
+
+	assertEqual(t, resp.Message, ErrUploadFailed, "")
+	assertEqual(t, resp.HttpResponse == nil, true, "")
+}
+
+func Test_CreateComposite(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	first, resp := client.UploadPartial(ctx, bytes.NewReader([]byte("%PDF-1.4")), UploadOptions{UserIdentifier: "user1"})
+	assertEqual(t, resp.Error, nil, "")
+
+	partials := []PartialDocument{
+		first.Partial(0),
+		{Document: "https://api.gini.net/documents/2", RotationDelta: 270},
+	}
+
+	doc, resp := client.CreateComposite(ctx, partials, UploadOptions{UserIdentifier: "user1", FileName: "invoice"})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Document"), "invoice||application/vnd.gini.v2.composite+json", "")
+	assertEqual(t, resp.HttpResponse.Header.Get("Upload-Body"), fmt.Sprintf(
+		`{"partialDocuments":[{"document":"%s/test/document/get","rotationDelta":0},{"document":"https://api.gini.net/documents/2","rotationDelta":270}]}`,
+		testHTTPServer.URL), "")
+
+	assertEqual(t, doc.PageCount, 2, "")
+	assertEqual(t, len(doc.PartialDocuments), 2, "")
+	assertEqual(t, doc.PartialDocuments[1].RotationDelta, 90, "")
+	assertEqual(t, doc.Owner, "user1", "")
+
+	_, resp = client.CreateComposite(ctx, nil, UploadOptions{UserIdentifier: "user1"})
+	assertEqual(t, resp.Message, ErrCompositeInvalid, "")
+
+	_, resp = client.CreateComposite(ctx, []PartialDocument{{Document: "x", RotationDelta: 45}}, UploadOptions{UserIdentifier: "user1"})
+	assertEqual(t, resp.Message, ErrCompositeInvalid, "")
+}
@@ -0,0 +1,300 @@
+// Copyright 2015-2018 The gini-api-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package girocode creates EPC QR codes (EPC069-12, also known as GiroCode) from
+the payment extractions of invoices processed by Gini. Banking apps scan these
+codes to prefill a SEPA credit transfer.
+
+	payment, err := girocode.FromExtractions(extractions)
+	if err != nil {
+		log.Printf("invalid payment data: %s", err)
+		return
+	}
+
+	payment.WritePNG(file, 8)
+
+ScanDocument reads GiroCodes and BezahlCodes from the page images of a
+document. The decoded payments can be merged into the extractions as
+additional candidates for the fields Gini missed.
+
+	matches, resp := girocode.ScanDocument(ctx, doc, "")
+	for _, match := range matches {
+		match.Merge(extractions)
+	}
+*/
+package girocode
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dkerwin/gini-api-go"
+	"github.com/dkerwin/gini-api-go/qrcode"
+)
+
+const (
+	// ServiceTag identifies EPC QR code payloads
+	ServiceTag = "BCD"
+	// Version of the payload format. BIC is optional in version 002.
+	Version = "002"
+
+	// maxPayload is the maximum payload size in bytes
+	maxPayload = 331
+	// maxVersion is the largest QR code version allowed by EPC069-12
+	maxVersion     = 13
+	maxAmountCents = 99999999999
+)
+
+var (
+	purposePattern   = regexp.MustCompile(`^[A-Z0-9]{4}$`)
+	referencePattern = regexp.MustCompile(`^RF[0-9]{2}[A-Z0-9]{1,21}$`)
+)
+
+// Payment holds the fields of an EPC QR code
+type Payment struct {
+	// BIC of the beneficiary bank (optional)
+	BIC string
+	// Name of the beneficiary (max. 70 characters)
+	Name string
+	// IBAN of the beneficiary account
+	IBAN string
+	// Amount in EUR (optional)
+	Amount giniapi.Amount
+	// Purpose is a four letter purpose code (optional)
+	Purpose string
+	// Reference is a structured creditor reference (ISO 11649, RF...)
+	Reference string
+	// Text is the unstructured remittance information (max. 140 characters).
+	// Reference and Text are mutually exclusive.
+	Text string
+	// Information is a note to the payer displayed by the banking app
+	Information string
+}
+
+// ValidationError describes a missing or malformed field
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// Error satisfies the error interface
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors collects all problems found in a payment
+type ValidationErrors []ValidationError
+
+// Error satisfies the error interface
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// FromExtractions maps the labels iban, bic, paymentRecipient, amountToPay
+// and paymentReference to a Payment. A paymentReference that is a valid
+// creditor reference is used as structured reference. Missing or malformed
+// fields are returned as ValidationErrors.
+func FromExtractions(e *giniapi.Extractions) (*Payment, error) {
+	if e == nil {
+		return nil, ValidationErrors{{"extractions", "missing"}}
+	}
+
+	payment := &Payment{
+		BIC:  strings.ToUpper(strings.TrimSpace(e.GetValue("bic"))),
+		Name: strings.TrimSpace(e.GetValue("paymentRecipient")),
+		IBAN: giniapi.NormalizeIBAN(e.GetValue("iban")),
+	}
+
+	var errs ValidationErrors
+
+	if value := e.GetValue("amountToPay"); value != "" {
+		if amount, err := giniapi.ParseAmount(value); err != nil {
+			errs = append(errs, ValidationError{"amountToPay", err.Error()})
+		} else {
+			payment.Amount = amount
+		}
+	}
+
+	if reference := strings.TrimSpace(e.GetValue("paymentReference")); validCreditorReference(reference) {
+		payment.Reference = strings.ToUpper(strings.Replace(reference, " ", "", -1))
+	} else {
+		payment.Text = reference
+	}
+
+	if err := payment.Validate(); err != nil {
+		errs = append(errs, err.(ValidationErrors)...)
+	}
+
+	if len(errs) > 0 {
+		return payment, errs
+	}
+
+	return payment, nil
+}
+
+// fieldLabels maps payment fields to the labels they are extracted from
+var fieldLabels = map[string]string{
+	"bic":       "bic",
+	"name":      "paymentRecipient",
+	"iban":      "iban",
+	"amount":    "amountToPay",
+	"purpose":   "purpose",
+	"reference": "paymentReference",
+	"text":      "paymentReference",
+}
+
+// Validate checks all fields against the EPC069-12 rules. Field names in the
+// returned ValidationErrors are the Gini labels the fields are mapped from.
+func (p *Payment) Validate() error {
+	var errs ValidationErrors
+
+	add := func(field, message string) {
+		errs = append(errs, ValidationError{fieldLabels[field], message})
+	}
+
+	if p.BIC != "" {
+		if err := giniapi.ValidateBIC(p.BIC); err != nil {
+			add("bic", err.Error())
+		}
+	}
+
+	if p.Name == "" {
+		add("name", "missing")
+	} else if utf8.RuneCountInString(p.Name) > 70 {
+		add("name", "exceeds 70 characters")
+	}
+
+	if p.IBAN == "" {
+		add("iban", "missing")
+	} else if err := giniapi.ValidateIBAN(p.IBAN); err != nil {
+		add("iban", err.Error())
+	}
+
+	if p.Amount != (giniapi.Amount{}) {
+		if p.Amount.Currency != "EUR" {
+			add("amount", fmt.Sprintf("unsupported currency %q", p.Amount.Currency))
+		} else if p.Amount.Cents <= 0 || p.Amount.Cents > maxAmountCents {
+			add("amount", "must be between 0.01 and 999999999.99")
+		}
+	}
+
+	if p.Purpose != "" && !purposePattern.MatchString(p.Purpose) {
+		add("purpose", "must be a four character code")
+	}
+
+	if p.Reference != "" && p.Text != "" {
+		add("reference", "reference and text are mutually exclusive")
+	}
+	if p.Reference != "" && !validCreditorReference(p.Reference) {
+		add("reference", "invalid creditor reference")
+	}
+	if utf8.RuneCountInString(p.Text) > 140 {
+		add("text", "exceeds 140 characters")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if len(p.payload()) > maxPayload {
+		return ValidationErrors{{"payload", fmt.Sprintf("exceeds %d bytes", maxPayload)}}
+	}
+
+	return nil
+}
+
+// Payload validates the payment and returns the EPC QR code text
+func (p *Payment) Payload() (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	return p.payload(), nil
+}
+
+// payload builds the EPC QR code text without validation
+func (p *Payment) payload() string {
+	amount := ""
+	if p.Amount != (giniapi.Amount{}) {
+		amount = "EUR" + p.Amount.Decimal()
+	}
+
+	lines := []string{
+		ServiceTag,
+		Version,
+		"1", // UTF-8
+		"SCT",
+		p.BIC,
+		p.Name,
+		giniapi.NormalizeIBAN(p.IBAN),
+		amount,
+		p.Purpose,
+		p.Reference,
+		p.Text,
+		p.Information,
+	}
+
+	// trailing empty fields can be omitted
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Encode validates the payment and returns the QR code with error correction
+// level M as required by EPC069-12
+func (p *Payment) Encode() (*qrcode.Code, error) {
+	payload, err := p.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return qrcode.EncodeVersion([]byte(payload), qrcode.Medium, 1, maxVersion)
+}
+
+// WritePNG writes the QR code as PNG image with scale pixels per module
+func (p *Payment) WritePNG(w io.Writer, scale int) error {
+	code, err := p.Encode()
+	if err != nil {
+		return err
+	}
+	return code.WritePNG(w, scale)
+}
+
+// WriteSVG writes the QR code as SVG image of the given size
+func (p *Payment) WriteSVG(w io.Writer, size int) error {
+	code, err := p.Encode()
+	if err != nil {
+		return err
+	}
+	return code.WriteSVG(w, size)
+}
+
+// validCreditorReference checks a ISO 11649 creditor reference (RF...)
+func validCreditorReference(reference string) bool {
+	reference = strings.ToUpper(strings.Replace(reference, " ", "", -1))
+	if !referencePattern.MatchString(reference) {
+		return false
+	}
+
+	var digits strings.Builder
+	for _, r := range reference[4:] + reference[:4] {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(fmt.Sprintf("%d", r-'A'+10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
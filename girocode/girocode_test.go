@@ -0,0 +1,112 @@
+package girocode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/dkerwin/gini-api-go"
+)
+
+func testExtractions(values map[string]string) *giniapi.Extractions {
+	e := &giniapi.Extractions{Extractions: map[string]giniapi.Extraction{}}
+	for label, value := range values {
+		e.Extractions[label] = giniapi.Extraction{Value: value}
+	}
+	return e
+}
+
+func Test_FromExtractions(t *testing.T) {
+	payment, err := FromExtractions(testExtractions(map[string]string{
+		"iban":             "DE02 1203 0000 0000 2020 51",
+		"bic":              "BYLADEM1001",
+		"amountToPay":      "24.9:EUR",
+		"paymentRecipient": "Gini GmbH",
+		"paymentReference": "Rechnung 4711",
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	payload, err := payment.Payload()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := "BCD\n002\n1\nSCT\nBYLADEM1001\nGini GmbH\nDE02120300000000202051\nEUR24.90\n\n\nRechnung 4711"
+	if payload != expected {
+		t.Errorf("Payload mismatch:\n%q\n%q", payload, expected)
+	}
+
+	// creditor references are used as structured reference
+	payment, _ = FromExtractions(testExtractions(map[string]string{
+		"iban":             "DE02120300000000202051",
+		"paymentRecipient": "Gini GmbH",
+		"paymentReference": "RF18 5390 0754 7034",
+	}))
+	if payment.Reference != "RF18539007547034" || payment.Text != "" {
+		t.Errorf("Creditor reference not detected: %#v", payment)
+	}
+}
+
+func Test_FromExtractionsValidation(t *testing.T) {
+	_, err := FromExtractions(testExtractions(map[string]string{
+		"iban":        "DE02120300000000202052",
+		"amountToPay": "24.99:USD",
+	}))
+
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("Expected 3 validation errors, got %v", err)
+	}
+
+	fields := []string{errs[0].Field, errs[1].Field, errs[2].Field}
+	if strings.Join(fields, ",") != "paymentRecipient,iban,amountToPay" {
+		t.Errorf("Unexpected fields: %v", fields)
+	}
+
+	if _, err := FromExtractions(nil); err == nil || err.Error() != "extractions: missing" {
+		t.Errorf("Unexpected error for nil extractions: %v", err)
+	}
+
+	payment := Payment{Name: "Gini GmbH", IBAN: "DE02120300000000202051", Text: strings.Repeat("x", 141)}
+	if _, err := payment.Payload(); err == nil {
+		t.Errorf("Text longer than 140 characters should fail")
+	}
+}
+
+func Test_PaymentWriteImages(t *testing.T) {
+	payment := Payment{
+		Name:   "Gini GmbH",
+		IBAN:   "DE02120300000000202051",
+		Amount: giniapi.Amount{Cents: 2499, Currency: "EUR"},
+		Text:   "Rechnung 4711",
+	}
+
+	code, err := payment.Encode()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if code.Level.String() != "M" {
+		t.Errorf("EPC QR codes require level M, got %s", code.Level)
+	}
+
+	var buf bytes.Buffer
+	if err := payment.WritePNG(&buf, 4); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Errorf("Invalid PNG: %s", err)
+	}
+
+	buf.Reset()
+	if err := payment.WriteSVG(&buf, 256); err != nil || !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Errorf("Invalid SVG: %s", err)
+	}
+
+	payment.IBAN = ""
+	if err := payment.WritePNG(&buf, 4); err == nil {
+		t.Errorf("Missing IBAN should fail")
+	}
+}
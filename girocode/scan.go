@@ -0,0 +1,272 @@
+package girocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dkerwin/gini-api-go"
+	"github.com/dkerwin/gini-api-go/qrcode"
+)
+
+// ErrPayloadInvalid is returned for QR codes that hold no payment
+const ErrPayloadInvalid = "invalid payment code payload"
+
+// Match is a payment code found in a page image
+type Match struct {
+	Payment *Payment
+	// Page number the code was found on (0 for Scan)
+	Page int
+	// Bounds of the code in image pixels
+	Bounds image.Rectangle
+	// Errors of fields that failed validation, these fields are not merged
+	Errors ValidationErrors
+}
+
+// ParsePayload parses the content of an EPC QR code (GiroCode) or a SEPA
+// BezahlCode (bank://singlepaymentsepa?...). Payloads which are no payment
+// codes return ErrPayloadInvalid, payments with malformed fields are returned
+// together with their ValidationErrors.
+func ParsePayload(data []byte) (*Payment, error) {
+	var payment *Payment
+	var err error
+
+	text := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(text, ServiceTag+"\n") || strings.HasPrefix(text, ServiceTag+"\r\n"):
+		payment, err = parseEPC(data)
+	case strings.HasPrefix(strings.ToLower(text), "bank://"):
+		payment, err = parseBezahlCode(text)
+	default:
+		err = errors.New(ErrPayloadInvalid)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := payment.Validate(); err != nil {
+		return payment, err
+	}
+
+	return payment, nil
+}
+
+// parseEPC parses the line based EPC069-12 format
+func parseEPC(data []byte) (*Payment, error) {
+	lines := strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n")
+	if len(lines) < 7 {
+		return nil, errors.New(ErrPayloadInvalid)
+	}
+
+	if lines[1] != "001" && lines[1] != Version {
+		return nil, fmt.Errorf("%s: unsupported version %q", ErrPayloadInvalid, lines[1])
+	}
+	if lines[3] != "SCT" {
+		return nil, fmt.Errorf("%s: unsupported identification %q", ErrPayloadInvalid, lines[3])
+	}
+
+	switch lines[2] {
+	case "1":
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("%s: invalid UTF-8", ErrPayloadInvalid)
+		}
+	case "2":
+		// ISO 8859-1 maps byte by byte to the first 256 code points
+		for i, line := range lines {
+			runes := make([]rune, len(line))
+			for j := 0; j < len(line); j++ {
+				runes[j] = rune(line[j])
+			}
+			lines[i] = string(runes)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported character set %q", ErrPayloadInvalid, lines[2])
+	}
+
+	field := func(i int) string {
+		if i < len(lines) {
+			return strings.TrimSpace(lines[i])
+		}
+		return ""
+	}
+
+	payment := &Payment{
+		BIC:         field(4),
+		Name:        field(5),
+		IBAN:        giniapi.NormalizeIBAN(field(6)),
+		Purpose:     field(8),
+		Reference:   field(9),
+		Text:        field(10),
+		Information: field(11),
+	}
+
+	if amount := field(7); amount != "" {
+		if !strings.HasPrefix(amount, "EUR") {
+			return nil, fmt.Errorf("%s: invalid amount %q", ErrPayloadInvalid, amount)
+		}
+		value, err := giniapi.ParseAmount(amount[3:] + ":EUR")
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid amount %q", ErrPayloadInvalid, amount)
+		}
+		payment.Amount = value
+	}
+
+	return payment, nil
+}
+
+// parseBezahlCode parses a BezahlCode URI. Only SEPA single payments are
+// supported.
+func parseBezahlCode(text string) (*Payment, error) {
+	u, err := url.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", ErrPayloadInvalid, err)
+	}
+	if !strings.EqualFold(u.Host, "singlepaymentsepa") {
+		return nil, fmt.Errorf("%s: unsupported BezahlCode %q", ErrPayloadInvalid, u.Host)
+	}
+
+	params := url.Values{}
+	for key, values := range u.Query() {
+		params[strings.ToLower(key)] = values
+	}
+
+	payment := &Payment{
+		BIC:  strings.ToUpper(strings.TrimSpace(params.Get("bic"))),
+		Name: strings.TrimSpace(params.Get("name")),
+		IBAN: giniapi.NormalizeIBAN(params.Get("iban")),
+	}
+
+	if reason := strings.TrimSpace(params.Get("reason")); validCreditorReference(reason) {
+		payment.Reference = strings.ToUpper(strings.Replace(reason, " ", "", -1))
+	} else {
+		payment.Text = reason
+	}
+
+	if amount := strings.TrimSpace(params.Get("amount")); amount != "" {
+		currency := strings.ToUpper(params.Get("currency"))
+		if currency == "" {
+			currency = "EUR"
+		}
+		value, err := giniapi.ParseAmount(strings.Replace(amount, ",", ".", 1) + ":" + currency)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid amount %q", ErrPayloadInvalid, amount)
+		}
+		payment.Amount = value
+	}
+
+	return payment, nil
+}
+
+// Scan decodes all payment codes in img. QR codes which hold no payment are
+// skipped. It returns an error if no payment code was found.
+func Scan(img image.Image) ([]Match, error) {
+	results, err := qrcode.Decode(img)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, result := range results {
+		payment, err := ParsePayload(result.Data)
+		if payment == nil {
+			continue
+		}
+		errs, ok := err.(ValidationErrors)
+		if err != nil && !ok {
+			continue
+		}
+		matches = append(matches, Match{Payment: payment, Bounds: result.Bounds, Errors: errs})
+	}
+
+	if len(matches) == 0 {
+		return nil, errors.New(ErrPayloadInvalid)
+	}
+
+	return matches, nil
+}
+
+// ScanDocument downloads the page images of a document in the given size
+// (empty for the largest) and scans them for payment codes. Pages without a
+// payment code are skipped, a failed download aborts the scan.
+func ScanDocument(ctx context.Context, d *giniapi.Document, size string) ([]Match, giniapi.APIResponse) {
+	var matches []Match
+	var resp giniapi.APIResponse
+
+	for _, page := range d.Pages {
+		var img image.Image
+		img, resp = d.GetPageImage(ctx, page.PageNumber, size)
+		if resp.Error != nil {
+			return matches, resp
+		}
+
+		found, err := Scan(img)
+		if err != nil {
+			continue
+		}
+		for _, match := range found {
+			match.Page = page.PageNumber
+			matches = append(matches, match)
+		}
+	}
+
+	return matches, resp
+}
+
+// entities of the labels a payment is merged into
+var labelEntities = map[string]string{
+	"iban":             "iban",
+	"bic":              "bic",
+	"paymentRecipient": "companyname",
+	"amountToPay":      "amount",
+	"paymentReference": "reference",
+}
+
+// Merge adds the valid payment fields as candidates to the extractions.
+// Labels without extraction get the decoded value as extraction. The
+// candidate boxes only carry the page number. Candidates are added to the
+// candidates list of the extraction, which may be shared with other labels
+// (e.g. "amounts"). It returns the labels a candidate was added to.
+func (m Match) Merge(e *giniapi.Extractions) []string {
+	p := m.Payment
+
+	values := map[string]string{
+		"iban":             p.IBAN,
+		"bic":              p.BIC,
+		"paymentRecipient": p.Name,
+		"paymentReference": p.Reference,
+	}
+	if p.Text != "" {
+		values["paymentReference"] = p.Text
+	}
+	if p.Amount != (giniapi.Amount{}) {
+		values["amountToPay"] = p.Amount.String()
+	}
+
+	for _, err := range m.Errors {
+		delete(values, err.Field)
+	}
+
+	var labels []string
+	for _, label := range []string{"amountToPay", "bic", "iban", "paymentRecipient", "paymentReference"} {
+		value := values[label]
+		if value == "" {
+			continue
+		}
+
+		candidate := giniapi.Extraction{
+			Box:    giniapi.Box{Page: m.Page},
+			Entity: labelEntities[label],
+			Value:  value,
+		}
+		if e.AddCandidate(label, candidate) {
+			labels = append(labels, label)
+		}
+	}
+
+	return labels
+}
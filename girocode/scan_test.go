@@ -0,0 +1,188 @@
+package girocode
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dkerwin/gini-api-go"
+)
+
+func testPayment() *Payment {
+	return &Payment{
+		BIC:    "BYLADEM1001",
+		Name:   "Gini GmbH",
+		IBAN:   "DE02120300000000202051",
+		Amount: giniapi.Amount{Cents: 2499, Currency: "EUR"},
+		Text:   "Rechnung 4711",
+	}
+}
+
+// testPage renders the payment code onto a white page with some text like
+// noise around it
+func testPage(t *testing.T, p *Payment) image.Image {
+	code, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	page := image.NewRGBA(image.Rect(0, 0, 800, 1100))
+	draw.Draw(page, page.Bounds(), image.White, image.Point{}, draw.Src)
+	for y := 80; y < 500; y += 24 {
+		draw.Draw(page, image.Rect(60, y, 60+(y*7)%500+100, y+9), image.NewUniform(color.Gray{40}), image.Point{}, draw.Src)
+	}
+
+	qr := code.Image(4)
+	draw.Draw(page, qr.Bounds().Add(image.Pt(480, 700)), qr, image.Point{}, draw.Src)
+
+	return page
+}
+
+func Test_ParsePayload(t *testing.T) {
+	payment, err := ParsePayload([]byte("BCD\n002\n1\nSCT\nBYLADEM1001\nGini GmbH\nDE02120300000000202051\nEUR24.99\n\n\nRechnung 4711"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if *payment != *testPayment() {
+		t.Errorf("Payment mismatch: %#v", payment)
+	}
+
+	// ISO 8859-1 with CRLF line breaks
+	payment, err = ParsePayload([]byte("BCD\r\n001\r\n2\r\nSCT\r\nBYLADEM1001\r\nM\xfcller\r\nDE02120300000000202051\r\nEUR1.5\r\n\r\nRF18539007547034"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if payment.Name != "Müller" || payment.Amount.Cents != 150 || payment.Reference != "RF18539007547034" {
+		t.Errorf("Payment mismatch: %#v", payment)
+	}
+
+	// BezahlCode
+	payment, err = ParsePayload([]byte("bank://singlepaymentsepa?name=Gini%20GmbH&reason=Rechnung%204711&iban=DE02120300000000202051&bic=BYLADEM1001&amount=24,99"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if *payment != *testPayment() {
+		t.Errorf("Payment mismatch: %#v", payment)
+	}
+
+	// malformed fields are returned with the payment
+	payment, err = ParsePayload([]byte("BCD\n002\n1\nSCT\n\nGini GmbH\nDE02120300000000202052"))
+	if _, ok := err.(ValidationErrors); !ok || payment == nil {
+		t.Errorf("Expected validation errors, got %v", err)
+	}
+
+	for _, payload := range []string{
+		"https://www.gini.net",
+		"BCD\n003\n1\nSCT\n\nGini GmbH\nDE02120300000000202051",
+		"BCD\n002\n9\nSCT\n\nGini GmbH\nDE02120300000000202051",
+		"BCD\n002\n1\nSCT\n\nGini GmbH\nDE02120300000000202051\nUSD1.00",
+		"bank://singlepayment?name=Gini&account=1234&bnc=12030000",
+	} {
+		if _, err := ParsePayload([]byte(payload)); err == nil {
+			t.Errorf("Expected error for %q", payload)
+		}
+	}
+}
+
+func Test_Scan(t *testing.T) {
+	matches, err := Scan(testPage(t, testPayment()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(matches) != 1 || *matches[0].Payment != *testPayment() {
+		t.Fatalf("Unexpected matches: %#v", matches)
+	}
+	if !matches[0].Bounds.In(image.Rect(470, 690, 800, 1100)) {
+		t.Errorf("Unexpected bounds: %s", matches[0].Bounds)
+	}
+
+	if _, err := Scan(image.NewGray(image.Rect(0, 0, 200, 200))); err == nil {
+		t.Errorf("Expected error for an empty image")
+	}
+}
+
+func Test_ScanDocument(t *testing.T) {
+	var page bytes.Buffer
+	png.Encode(&page, testPage(t, testPayment()))
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/documents/1234":
+			fmt.Fprintf(w, `{"id": "1234", "pageCount": 2, "pages": [
+				{"pageNumber": 1, "images": {"800x1100": "%[1]s/pages/1"}},
+				{"pageNumber": 2, "images": {"800x1100": "%[1]s/pages/2"}}
+			]}`, server.URL)
+		case "/pages/1":
+			png.Encode(w, image.NewGray(image.Rect(0, 0, 800, 1100)))
+		case "/pages/2":
+			w.Write(page.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := giniapi.NewClient(&giniapi.Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Authentication: giniapi.UseBasicAuth,
+		Endpoints:      giniapi.Endpoints{API: server.URL, UserCenter: server.URL},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	ctx := context.Background()
+	doc, resp := client.Get(ctx, server.URL+"/documents/1234", "user123")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %s", resp.Error)
+	}
+
+	matches, resp := ScanDocument(ctx, doc, "")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %s", resp.Error)
+	}
+	if len(matches) != 1 || matches[0].Page != 2 {
+		t.Fatalf("Unexpected matches: %#v", matches)
+	}
+
+	extractions := testExtractions(map[string]string{
+		"iban":        "DE02120300000000202051",
+		"amountToPay": "2.49:EUR",
+	})
+	labels := matches[0].Merge(extractions)
+
+	if fmt.Sprint(labels) != "[amountToPay bic paymentRecipient paymentReference]" {
+		t.Errorf("Unexpected merged labels: %v", labels)
+	}
+	if extractions.GetValue("amountToPay") != "2.49:EUR" {
+		t.Errorf("Existing extraction must not be replaced")
+	}
+	if candidates := extractions.Candidates["amountToPay"]; len(candidates) != 1 || candidates[0].Value != "24.99:EUR" || candidates[0].Page != 2 {
+		t.Errorf("Unexpected candidates: %#v", candidates)
+	}
+	if extractions.GetValue("paymentRecipient") != "Gini GmbH" {
+		t.Errorf("Missing extraction not created")
+	}
+
+	// fields failing validation are not merged
+	payment, err := ParsePayload([]byte("BCD\n002\n1\nSCT\n\nGini GmbH\nDE02120300000000202052\nEUR24.99"))
+	errs, _ := err.(ValidationErrors)
+	extractions = testExtractions(map[string]string{})
+	labels = Match{Payment: payment, Page: 1, Errors: errs}.Merge(extractions)
+
+	if fmt.Sprint(labels) != "[amountToPay paymentRecipient]" {
+		t.Errorf("Unexpected merged labels: %v", labels)
+	}
+	if extractions.GetValue("iban") != "" {
+		t.Errorf("Invalid IBAN must not be merged")
+	}
+}
@@ -0,0 +1,218 @@
+package giniapi
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// WordLinks holds the layout words covered by the boxes of extractions and
+// candidates. It mirrors the structure of Extractions.
+type WordLinks struct {
+	// Extractions maps labels to the words of the extraction box
+	Extractions map[string][]Word
+	// Candidates maps candidate keys to the words of each candidate box
+	Candidates map[string][][]Word
+}
+
+// LinkWords finds the words of the layout every extraction and candidate box
+// overlaps. Boxes without size (e.g. candidates from payment codes) have no
+// words.
+func (e *Extractions) LinkWords(index *LayoutIndex) WordLinks {
+	links := WordLinks{
+		Extractions: make(map[string][]Word, len(e.Extractions)),
+		Candidates:  make(map[string][][]Word, len(e.Candidates)),
+	}
+
+	for label, extraction := range e.Extractions {
+		links.Extractions[label] = index.WordsInBox(extraction.Box)
+	}
+
+	for key, candidates := range e.Candidates {
+		words := make([][]Word, len(candidates))
+		for i, candidate := range candidates {
+			words[i] = index.WordsInBox(candidate.Box)
+		}
+		links.Candidates[key] = words
+	}
+
+	return links
+}
+
+// highlightPalette are the default colors assigned to labels by
+// highlightColor
+var highlightPalette = []color.NRGBA{
+	{230, 25, 75, 255},
+	{60, 180, 75, 255},
+	{0, 130, 200, 255},
+	{245, 130, 48, 255},
+	{145, 30, 180, 255},
+	{70, 200, 200, 255},
+	{240, 50, 230, 255},
+	{128, 128, 0, 255},
+}
+
+// highlightColor returns the palette color of a label, which is the same on
+// every call regardless of the other labels
+func highlightColor(label string) color.Color {
+	h := fnv.New32a()
+	h.Write([]byte(label))
+	return highlightPalette[h.Sum32()%uint32(len(highlightPalette))]
+}
+
+// HighlightOptions specify parameters to the highlight functions
+type HighlightOptions struct {
+	// Labels to highlight. Defaults to all extracted labels.
+	Labels []string
+	// Colors per label. Labels without color get one of a default palette.
+	Colors map[string]color.Color
+	// Candidates also outlines the candidates of the labels
+	Candidates bool
+	// Words outlines the linked layout words instead of the extraction boxes
+	Words bool
+	// LineWidth of the outlines in pixels (default 2)
+	LineWidth int
+}
+
+// Highlight is a colored box drawn over a page image
+type Highlight struct {
+	Box   PageCoordinates
+	Color color.Color
+	// Fill draws a translucent area in addition to the outline
+	Fill bool
+}
+
+// Highlights returns the boxes to draw for the labels on the given page
+func (e *Extractions) Highlights(page *PageLayout, options HighlightOptions) []Highlight {
+	labels := append([]string(nil), options.Labels...)
+	if len(labels) == 0 {
+		for label := range e.Extractions {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	var index *PageIndex
+	if options.Words {
+		index = page.Index()
+	}
+
+	// outline the words under the box or the box itself
+	boxes := func(box Box) []PageCoordinates {
+		if box.Page != page.Number {
+			return nil
+		}
+		if index == nil {
+			return []PageCoordinates{box.Coordinates()}
+		}
+		var coordinates []PageCoordinates
+		for _, word := range index.WordsIn(box.Coordinates()) {
+			coordinates = append(coordinates, word.PageCoordinates)
+		}
+		return coordinates
+	}
+
+	var highlights []Highlight
+	for _, label := range labels {
+		extraction, ok := e.Extractions[label]
+		if !ok {
+			continue
+		}
+
+		c, ok := options.Colors[label]
+		if !ok {
+			c = highlightColor(label)
+		}
+
+		if options.Candidates {
+			for _, candidate := range e.Candidates[extraction.Candidates] {
+				for _, box := range boxes(candidate.Box) {
+					highlights = append(highlights, Highlight{Box: box, Color: c})
+				}
+			}
+		}
+
+		for _, box := range boxes(extraction.Box) {
+			highlights = append(highlights, Highlight{Box: box, Color: c, Fill: true})
+		}
+	}
+
+	return highlights
+}
+
+// DrawHighlights returns a copy of img with the highlights drawn over it.
+// Page coordinates are scaled from the page size (SizeX, SizeY) to the image
+// resolution.
+func DrawHighlights(img image.Image, page *PageLayout, highlights []Highlight, lineWidth int) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	if lineWidth <= 0 {
+		lineWidth = 2
+	}
+
+	scaleX, scaleY := 1.0, 1.0
+	if page.SizeX > 0 && page.SizeY > 0 {
+		scaleX = float64(bounds.Dx()) / page.SizeX
+		scaleY = float64(bounds.Dy()) / page.SizeY
+	}
+
+	for _, h := range highlights {
+		r := image.Rect(
+			int(math.Floor(h.Box.L*scaleX)), int(math.Floor(h.Box.T*scaleY)),
+			int(math.Ceil(h.Box.Right()*scaleX)), int(math.Ceil(h.Box.Bottom()*scaleY)),
+		).Inset(-lineWidth)
+
+		c := color.NRGBAModel.Convert(h.Color).(color.NRGBA)
+
+		if h.Fill {
+			fill := c
+			fill.A = c.A / 4
+			draw.Draw(dst, r, image.NewUniform(fill), image.Point{}, draw.Over)
+		}
+
+		outline := image.NewUniform(c)
+		for _, edge := range []image.Rectangle{
+			image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+lineWidth),
+			image.Rect(r.Min.X, r.Max.Y-lineWidth, r.Max.X, r.Max.Y),
+			image.Rect(r.Min.X, r.Min.Y, r.Min.X+lineWidth, r.Max.Y),
+			image.Rect(r.Max.X-lineWidth, r.Min.Y, r.Max.X, r.Max.Y),
+		} {
+			draw.Draw(dst, edge, outline, image.Point{}, draw.Over)
+		}
+	}
+
+	return dst
+}
+
+// HighlightPage downloads a page image in the given size (empty for the
+// largest) and draws the extractions over it. The layout provides the page
+// size and is fetched if nil.
+func (d *Document) HighlightPage(ctx context.Context, pageNumber int, size string, layout *Layout, extractions *Extractions, options HighlightOptions) (*image.RGBA, APIResponse) {
+	if layout == nil {
+		var resp APIResponse
+		if layout, resp = d.GetLayout(ctx); resp.Error != nil {
+			return nil, resp
+		}
+	}
+
+	page := layout.Page(pageNumber)
+	if page == nil {
+		return nil, apiResponse(ErrDocumentLayout, d.ID, nil, errors.New(ErrDocumentLayout))
+	}
+
+	img, resp := d.GetPageImage(ctx, pageNumber, size)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	highlights := extractions.Highlights(page, options)
+
+	return DrawHighlights(img, page, highlights, options.LineWidth), resp
+}
@@ -0,0 +1,86 @@
+package giniapi
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func testHighlightExtractions() *Extractions {
+	return &Extractions{
+		Extractions: map[string]Extraction{
+			"iban":        {Box: Box{Page: 1, Left: 80, Top: 701, Width: 100, Height: 5}, Value: "DE02120300000000202051", Candidates: "ibans"},
+			"amountToPay": {Box: Box{Page: 1, Left: 320, Top: 114, Width: 40, Height: 5}, Value: "24.99:EUR", Candidates: "amounts"},
+		},
+		Candidates: map[string][]Extraction{
+			"amounts": {
+				{Box: Box{Page: 1, Left: 320, Top: 114, Width: 40, Height: 5}, Value: "24.99:EUR"},
+				{Box: Box{Page: 1, Left: 300, Top: 114, Width: 5, Height: 5}, Value: "2.00:EUR"},
+				{Box: Box{Page: 1}, Value: "24.99:EUR"},
+			},
+		},
+	}
+}
+
+func Test_ExtractionsLinkWords(t *testing.T) {
+	layout := Layout{Pages: []PageLayout{*testInvoicePage()}}
+	links := testHighlightExtractions().LinkWords(layout.Index())
+
+	assertEqual(t, wordTexts(links.Extractions["iban"]), "[DE02120300000000202051]", "")
+	assertEqual(t, wordTexts(links.Extractions["amountToPay"]), "[24,99]", "")
+	assertEqual(t, len(links.Candidates["amounts"]), 3, "")
+	assertEqual(t, wordTexts(links.Candidates["amounts"][1]), "[2]", "")
+	assertEqual(t, len(links.Candidates["amounts"][2]), 0, "box without size")
+}
+
+func Test_DrawHighlights(t *testing.T) {
+	page := testInvoicePage()
+	extractions := testHighlightExtractions()
+
+	highlights := extractions.Highlights(page, HighlightOptions{Labels: []string{"amountToPay"}, Candidates: true})
+	assertEqual(t, len(highlights), 4, "")
+
+	highlights = extractions.Highlights(page, HighlightOptions{Labels: []string{"iban"}, Words: true})
+	assertEqual(t, len(highlights), 1, "")
+	assertEqual(t, highlights[0].Box.L, 75.0, "outlines the word")
+
+	// labels are not reordered and keep their color in any selection
+	labels := []string{"iban", "amountToPay"}
+	highlights = extractions.Highlights(page, HighlightOptions{Labels: labels})
+	assertEqual(t, labels[0], "iban", "")
+	assertEqual(t, len(highlights), 2, "")
+	assertEqual(t, highlights[0].Color, highlightColor("amountToPay"), "")
+	assertEqual(t, highlights[1].Color, highlightColor("iban"), "")
+	assertEqual(t, extractions.Highlights(page, HighlightOptions{})[1].Color, highlightColor("iban"), "")
+
+	// the image has twice the resolution of the page
+	img := image.NewGray(image.Rect(0, 0, 1190, 1684))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+
+	red := color.NRGBA{255, 0, 0, 255}
+	result := DrawHighlights(img, page, []Highlight{{Box: PageCoordinates{L: 100, T: 100, W: 50, H: 10}, Color: red, Fill: true}}, 2)
+
+	assertEqual(t, result.Bounds(), img.Bounds(), "")
+	assertEqual(t, color.RGBAModel.Convert(result.At(199, 210)), color.RGBA{255, 0, 0, 255}, "outline")
+	assertNotEqual(t, color.RGBAModel.Convert(result.At(250, 210)), color.RGBA{255, 255, 255, 255}, "filled")
+	assertEqual(t, color.RGBAModel.Convert(result.At(250, 150)), color.RGBA{255, 255, 255, 255}, "untouched")
+}
+
+func Test_DocumentHighlightPage(t *testing.T) {
+	client := testBasicAuthClient(t)
+	ctx := context.Background()
+
+	doc, resp := client.Get(ctx, testHTTPServer.URL+"/test/document/get", "user123")
+	assertEqual(t, resp.Error, nil, "")
+
+	layout := &Layout{Pages: []PageLayout{*testInvoicePage()}}
+	img, resp := doc.HighlightPage(ctx, 1, "750x900", layout, testHighlightExtractions(), HighlightOptions{})
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, img.Bounds().Dx(), 750, "")
+
+	_, resp = doc.HighlightPage(ctx, 2, "", layout, testHighlightExtractions(), HighlightOptions{})
+	assertNotEqual(t, resp.Error, nil, "")
+}
@@ -1,19 +1,29 @@
 package giniapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
-	// "io/ioutil"
+	"image"
+	"image/png"
+	"io/ioutil"
 	// "log"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	// "strconv"
-	// "time"
+	"time"
 )
 
 var (
 	testHTTPServer *httptest.Server
+	// testLastComposite is the body of the last composite document created
+	testLastComposite struct {
+		sync.Mutex
+		body string
+	}
 )
 
 func init() {
@@ -23,17 +33,23 @@ func init() {
 	r.HandleFunc("/oauth/token", handlerPostToken).Methods("POST")
 	r.HandleFunc("/documents", handlerTestDocumentList).Methods("GET")
 	r.HandleFunc("/documents", handlerTestDocumentUpload).Methods("POST")
+	r.HandleFunc("/documents/{id}", handlerTestDocumentByID).Methods("GET")
 	r.HandleFunc("/search", handlerTestDocumentSearch).Methods("GET")
 	r.HandleFunc("/test/http/basicAuth", handlerTestHTTPBasicAuth).Methods("GET")
 	r.HandleFunc("/test/http/oauth2", handlerTestHTTPOauth2).Methods("GET")
 	r.HandleFunc("/test/document/get", handlerTestDocumentGet).Methods("GET")
+	r.HandleFunc("/test/document/composite", handlerTestDocumentComposite).Methods("GET")
 	r.HandleFunc("/test/document/update", handlerTestDocumentUpdate).Methods("GET")
 	r.HandleFunc("/test/document/delete", handlerTestDocumentDelete).Methods("DELETE")
 	r.HandleFunc("/test/document/errorreport", handlerTestDocumentErrorReport).Methods("POST")
 	r.HandleFunc("/test/layout", handlerTestDocumentLayout).Methods("GET")
 	r.HandleFunc("/test/extractions", handlerTestDocumentExtractions).Methods("GET")
 	r.HandleFunc("/test/processed", handlerTestDocumentProcessed).Methods("GET")
+	r.HandleFunc("/test/processed/{mode}", handlerTestDocumentProcessedRange).Methods("GET")
+	r.HandleFunc("/test/source/{name}", handlerTestUploadSource).Methods("GET")
+	r.HandleFunc("/test/pages/{page}/{size}", handlerTestDocumentPageImage).Methods("GET")
 	r.HandleFunc("/test/feedback", handlerTestDocumentFeedback).Methods("PUT")
+	r.HandleFunc("/test/feedback/{label}", handlerTestDocumentLabelFeedback).Methods("PUT")
 
 	testHTTPServer = httptest.NewServer(handlerAccessLog(r))
 }
@@ -112,6 +128,13 @@ func handlerTestDocumentErrorReport(w http.ResponseWriter, r *http.Request) {
 }
 
 func handlerTestDocumentLayout(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Accept") == "application/vnd.gini.v1.layout+xml" {
+		w.Header().Set("Content-Type", "application/vnd.gini.v1.layout+xml")
+		w.WriteHeader(200)
+		w.Write([]byte(`<document><page number="1" sizeX="595.3" sizeY="841.9"/></document>`))
+		return
+	}
+
 	writeHeaders(w, 200, "changes")
 	body := `{
 	  "pages": [
@@ -189,6 +212,39 @@ func handlerTestDocumentLayout(w http.ResponseWriter, r *http.Request) {
 
 func handlerTestDocumentExtractions(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, 200, "changes")
+
+	if r.Header.Get("Accept") == "application/vnd.gini.incubator+json" {
+		w.Write([]byte(`{
+		    "extractions": {
+		        "amountToPay": {
+		            "box": {
+		                "height": 9.0,
+		                "left": 516.0,
+		                "page": 1,
+		                "top": 588.0,
+		                "width": 42.0
+		            },
+		            "entity": "amount",
+		            "value": "24.99:EUR",
+		            "candidates": "amounts"
+		        },
+		        "paymentDueDate": {
+		            "box": {
+		                "height": 9.0,
+		                "left": 400.0,
+		                "page": 1,
+		                "top": 120.0,
+		                "width": 50.0
+		            },
+		            "entity": "date",
+		            "value": "2018-12-24"
+		        }
+		    },
+		    "candidates": {}
+		}`))
+		return
+	}
+
 	body := `{
 	    "extractions": {
 	        "amountToPay": {
@@ -240,6 +296,85 @@ func handlerTestDocumentProcessed(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("get processed"))
 }
 
+// testProcessedContent is the processed document of the range handler
+var testProcessedContent = bytes.Repeat([]byte("0123456789abcdef"), 4096)
+
+// testRangeRequests counts the range requests of the "dropped",
+// "unavailable" and "resent" modes
+var testRangeRequests int32
+
+// handlerTestDocumentProcessedRange breaks off the first download after half
+// of the content. Range requests are served from the complete content, in
+// mode "changed" with a new ETag. Every other range request fails: in mode
+// "dropped" with a broken response, in mode "unavailable" with a 503 and in
+// mode "resent" with a complete document that breaks off after a quarter.
+func handlerTestDocumentProcessedRange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/pdf")
+
+	if r.Header.Get("Range") == "" {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", fmt.Sprint(len(testProcessedContent)))
+		w.WriteHeader(200)
+		w.Write(testProcessedContent[:len(testProcessedContent)/2])
+		return
+	}
+
+	mode := mux.Vars(r)["mode"]
+	failing := false
+	if mode == "dropped" || mode == "unavailable" || mode == "resent" {
+		failing = atomic.AddInt32(&testRangeRequests, 1)%2 == 1
+	}
+
+	etag := `"v1"`
+	switch mode {
+	case "changed":
+		etag = `"v2"`
+	case "dropped":
+		if failing {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Write([]byte("HTTP/1.1 206 Partial Content\r\n"))
+			conn.Close()
+			return
+		}
+		// served without the complete length
+		var start int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/*", start, len(testProcessedContent)-1))
+		w.WriteHeader(206)
+		w.Write(testProcessedContent[start:])
+		return
+	case "unavailable":
+		if failing {
+			writeHeaders(w, 503, "")
+			return
+		}
+	case "resent":
+		// the range is ignored
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Length", fmt.Sprint(len(testProcessedContent)))
+		w.WriteHeader(200)
+		if failing {
+			w.Write(testProcessedContent[:len(testProcessedContent)/4])
+		} else {
+			w.Write(testProcessedContent)
+		}
+		return
+	}
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(testProcessedContent))
+}
+
+func handlerTestDocumentPageImage(w http.ResponseWriter, r *http.Request) {
+	var width, height int
+	if _, err := fmt.Sscanf(mux.Vars(r)["size"], "%dx%d", &width, &height); err != nil {
+		writeHeaders(w, 404, "not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, image.NewGray(image.Rect(0, 0, width, height)))
+}
+
 func handlerTestDocumentFeedback(w http.ResponseWriter, r *http.Request) {
 	var feedbackMap map[string]map[string]Extraction
 
@@ -251,13 +386,58 @@ func handlerTestDocumentFeedback(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, 204, "ok")
 }
 
+// handlerTestDocumentLabelFeedback rejects feedback with an empty box, which
+// would replace the stored box
+func handlerTestDocumentLabelFeedback(w http.ResponseWriter, r *http.Request) {
+	var extraction struct {
+		Value string
+		Box   *Box
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&extraction); err != nil || mux.Vars(r)["label"] == "broken" || (extraction.Box != nil && *extraction.Box == Box{}) {
+		writeHeaders(w, 500, "failed")
+		return
+	}
+
+	writeHeaders(w, 204, "ok")
+}
+
 func handlerTestDocumentUpload(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Location", fmt.Sprintf("%s/test/document/get", testHTTPServer.URL))
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Add("Upload-Length", fmt.Sprintf("%d/%d", r.ContentLength, len(body)))
+	w.Header().Add("Upload-Document", fmt.Sprintf("%s|%s|%s", r.URL.Query().Get("filename"), r.URL.Query().Get("doctype"), r.Header.Get("Content-Type")))
+
+	if r.Header.Get("Content-Type") == "application/vnd.gini.v2.composite+json" {
+		w.Header().Add("Upload-Body", string(body))
+		testLastComposite.Lock()
+		testLastComposite.body = string(body)
+		testLastComposite.Unlock()
+		w.Header().Add("Location", fmt.Sprintf("%s/test/document/composite", testHTTPServer.URL))
+	} else if r.URL.Query().Get("filename") == "missing.pdf" {
+		w.Header().Add("Location", fmt.Sprintf("%s/test/document/missing", testHTTPServer.URL))
+	} else {
+		w.Header().Add("Location", fmt.Sprintf("%s/test/document/get", testHTTPServer.URL))
+	}
 	writeHeaders(w, 201, "ok")
 
 	return
 }
 
+// handlerTestUploadSource serves documents to upload from, "missing.pdf" is
+// not found and "attachment" is served with a Content-Disposition
+func handlerTestUploadSource(w http.ResponseWriter, r *http.Request) {
+	switch mux.Vars(r)["name"] {
+	case "missing.pdf":
+		writeHeaders(w, 404, "")
+		return
+	case "attachment":
+		w.Header().Set("Content-Disposition", `attachment; filename="invoice 4711.pdf"`)
+	}
+
+	w.Header().Set("Content-Type", "application/pdf; qs=0.9")
+	w.Write([]byte("%PDF-1.4 test"))
+}
+
 func handlerTestDocumentGet(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, 200, "changes")
 	body := fmt.Sprintf(`{
@@ -271,8 +451,8 @@ func handlerTestDocumentGet(w http.ResponseWriter, r *http.Request) {
 		  "pages" : [
 		    {
 		      "images" : {
-		        "750x900" : "http://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/pages/1/750x900",
-		        "1280x1810" : "http://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/pages/1/1280x1810"
+		        "750x900" : "%[1]s/test/pages/1/750x900",
+		        "1280x1810" : "%[1]s/test/pages/1/1280x1810"
 		      },
 		      "pageNumber" : 1
 		    }
@@ -280,7 +460,7 @@ func handlerTestDocumentGet(w http.ResponseWriter, r *http.Request) {
 		  "_links": {
 		    "extractions": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/extractions",
 		    "layout": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/layout",
-		    "document": "%s/test/document/get",
+		    "document": "%[1]s/test/document/get",
 		    "processed": "https://api.gini.net/documents/626626a0-749f-11e2-bfd6-000000000000/processed"
 		  }
 		}`, testHTTPServer.URL)
@@ -288,6 +468,56 @@ func handlerTestDocumentGet(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(body))
 }
 
+// handlerTestDocumentByID serves the document of handlerTestDocumentGet for
+// its ID, other documents are not found
+func handlerTestDocumentByID(w http.ResponseWriter, r *http.Request) {
+	if mux.Vars(r)["id"] != "626626a0-749f-11e2-bfd6-000000000000" {
+		writeHeaders(w, 404, "")
+		return
+	}
+	handlerTestDocumentGet(w, r)
+}
+
+func handlerTestDocumentComposite(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, 200, "changes")
+	body := fmt.Sprintf(`{
+		  "id": "7a1c3d40-749f-11e2-bfd6-000000000000",
+		  "creationDate": 1360623867402,
+		  "name": "composite",
+		  "docType": "Invoice",
+		  "progress": "COMPLETED",
+		  "origin": "UPLOAD",
+		  "sourceClassification": "COMPOSITE",
+		  "pageCount": 2,
+		  "pages" : [
+		    {
+		      "images" : {
+		        "750x900" : "%[1]s/test/pages/1/750x900"
+		      },
+		      "pageNumber" : 1
+		    },
+		    {
+		      "images" : {
+		        "750x900" : "%[1]s/test/pages/2/750x900"
+		      },
+		      "pageNumber" : 2
+		    }
+		  ],
+		  "partialDocuments": [
+		    {"document": "%[1]s/test/document/partial/1", "rotationDelta": 0},
+		    {"document": "%[1]s/test/document/partial/2", "rotationDelta": 90}
+		  ],
+		  "_links": {
+		    "extractions": "%[1]s/test/extractions",
+		    "layout": "%[1]s/test/layout",
+		    "document": "%[1]s/test/document/composite",
+		    "processed": "%[1]s/test/processed"
+		  }
+		}`, testHTTPServer.URL)
+
+	w.Write([]byte(body))
+}
+
 func handlerTestDocumentList(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, 200, "changes")
 	body := `{
@@ -0,0 +1,199 @@
+package giniapi
+
+import (
+	"context"
+)
+
+// defaultPageSize is the page size of iterators without Limit
+const defaultPageSize = 100
+
+// listResult is a fetched page of a listing
+type listResult struct {
+	set    *DocumentSet
+	resp   APIResponse
+	offset int
+}
+
+// DocumentIterator walks all documents of a listing page by page:
+//
+//	it := api.Iterate(ctx, giniapi.ListOptions{UserIdentifier: "user123"})
+//	for it.Next() {
+//		doc := it.Document()
+//	}
+//	if resp := it.Response(); resp.Error != nil {
+//		...
+//	}
+//
+// Documents added or deleted between pages shift the offsets of the
+// following documents. The iterator moves back by the number of deleted
+// documents and skips documents already returned, so every document present
+// during the whole walk is returned exactly once, though not necessarily in
+// listing order.
+type DocumentIterator struct {
+	ctx      context.Context
+	api      *APIClient
+	options  ListOptions
+	offset   int
+	total    int
+	seen     map[string]bool
+	page     []*Document
+	current  *Document
+	pending  chan listResult
+	done     bool
+	sorted   bool
+	response APIResponse
+}
+
+// Iterate returns an iterator over all documents starting at options.Offset
+// passing the filters of the options. Limit is the page size (default 100).
+// With a sort order all documents are fetched by the first call of Next.
+func (api *APIClient) Iterate(ctx context.Context, options ListOptions) *DocumentIterator {
+	if options.Limit <= 0 {
+		options.Limit = defaultPageSize
+	}
+
+	it := &DocumentIterator{
+		ctx:     ctx,
+		api:     api,
+		options: options,
+		offset:  options.Offset,
+		total:   -1,
+		seen:    map[string]bool{},
+	}
+
+	if err := options.validate(); err != nil {
+		it.done = true
+		it.response = apiResponse(ErrListOptions, "", nil, err)
+	}
+
+	return it
+}
+
+// ListAll returns all documents of a listing, see Iterate
+func (api *APIClient) ListAll(ctx context.Context, options ListOptions) ([]*Document, APIResponse) {
+	var docs []*Document
+
+	it := api.Iterate(ctx, options)
+	for it.Next() {
+		docs = append(docs, it.Document())
+	}
+
+	resp := it.Response()
+	if resp.Error != nil {
+		return docs, resp
+	}
+
+	return docs, apiResponse("document listing completed", "", resp.HttpResponse, nil)
+}
+
+// fetch requests the page at offset
+func (it *DocumentIterator) fetch(offset int) listResult {
+	options := it.options
+	options.Offset = offset
+
+	set, resp := it.api.list(it.ctx, options)
+
+	return listResult{set: set, resp: resp, offset: offset}
+}
+
+// Next advances to the next document. It returns false when all documents
+// were returned, a request failed or the context was canceled.
+func (it *DocumentIterator) Next() bool {
+	it.current = nil
+
+	// sorting needs all documents
+	if it.options.SortBy != "" && !it.sorted {
+		it.sorted = true
+		for it.nextPage() {
+		}
+		if it.response.Error != nil {
+			it.page = nil
+			return false
+		}
+		it.options.sort(it.page)
+	}
+
+	if len(it.page) == 0 && !it.nextPage() {
+		return false
+	}
+
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// nextPage fetches pages until one has documents to return. The documents
+// are appended to the current page.
+func (it *DocumentIterator) nextPage() bool {
+	for added := false; !added; {
+		if it.done {
+			return false
+		}
+
+		if err := it.ctx.Err(); err != nil {
+			it.done = true
+			it.response = apiResponse("listing aborted", "", nil, err)
+			return false
+		}
+
+		var result listResult
+		if it.pending != nil {
+			result = <-it.pending
+			it.pending = nil
+		} else {
+			result = it.fetch(it.offset)
+		}
+
+		it.response = result.resp
+		if result.resp.Error != nil {
+			it.done = true
+			return false
+		}
+
+		set := result.set
+		next := result.offset + len(set.Documents)
+
+		// deleted documents move the following ones towards the front,
+		// possibly before this page. Continue before the page, documents
+		// already returned are skipped.
+		if it.total >= 0 && set.TotalCount < it.total {
+			next = result.offset - (it.total - set.TotalCount)
+			if next < 0 {
+				next = 0
+			}
+		}
+		it.total = set.TotalCount
+		it.offset = next
+
+		if len(set.Documents) == 0 || next >= set.TotalCount {
+			it.done = true
+		} else if it.options.Prefetch {
+			it.pending = make(chan listResult, 1)
+			go func(pending chan listResult, offset int) {
+				pending <- it.fetch(offset)
+			}(it.pending, next)
+		}
+
+		for _, doc := range set.Documents {
+			if !it.seen[doc.ID] {
+				it.seen[doc.ID] = true
+				if it.options.matches(doc) {
+					it.page = append(it.page, doc)
+					added = true
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// Document returns the current document
+func (it *DocumentIterator) Document() *Document {
+	return it.current
+}
+
+// Response returns the response of the last request. Its Error is set if
+// the walk ended early.
+func (it *DocumentIterator) Response() APIResponse {
+	return it.response
+}
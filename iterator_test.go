@@ -0,0 +1,207 @@
+package giniapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testListing serves a listing of documents, newest first. Change is called
+// before every request with the number of the request.
+// Documents are deleted by DELETE requests, unless listed in fail.
+type testListing struct {
+	sync.Mutex
+	ids      []string
+	requests int
+	change   func(l *testListing, request int)
+	created  map[string]time.Time
+	deleted  []string
+	fail     map[string]bool
+}
+
+func (l *testListing) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.Lock()
+	defer l.Unlock()
+
+	if r.Method == "DELETE" {
+		id := strings.TrimPrefix(r.URL.Path, "/documents/")
+		if l.fail[id] {
+			writeHeaders(w, 500, "")
+			return
+		}
+		for i := range l.ids {
+			if l.ids[i] == id {
+				l.ids = append(l.ids[:i], l.ids[i+1:]...)
+				l.deleted = append(l.deleted, id)
+				writeHeaders(w, 204, "")
+				return
+			}
+		}
+		writeHeaders(w, 404, "")
+		return
+	}
+
+	l.requests++
+	if l.change != nil {
+		l.change(l, l.requests)
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	set := DocumentSet{TotalCount: len(l.ids), Documents: []*Document{}}
+	for i := offset; i < len(l.ids) && i < offset+limit; i++ {
+		// even documents are PDFs, creation dates follow the IDs
+		number, _ := strconv.Atoi(l.ids[i])
+		name := fmt.Sprintf("%s.jpg", l.ids[i])
+		if number%2 == 0 {
+			name = fmt.Sprintf("%s.pdf", l.ids[i])
+		}
+		created, ok := l.created[l.ids[i]]
+		if !ok {
+			created = time.Unix(int64(number), 0)
+		}
+		set.Documents = append(set.Documents, &Document{
+			ID:           l.ids[i],
+			Name:         name,
+			CreationDate: created,
+			Links:        Links{Document: fmt.Sprintf("http://%s/documents/%s", r.Host, l.ids[i])},
+		})
+	}
+
+	writeHeaders(w, 200, "")
+	json.NewEncoder(w).Encode(set)
+}
+
+func testListingClient(t *testing.T, count int, change func(l *testListing, request int)) (*APIClient, *testListing, func()) {
+	listing := &testListing{change: change}
+	for i := count; i > 0; i-- {
+		listing.ids = append(listing.ids, fmt.Sprint(i))
+	}
+
+	server := httptest.NewServer(listing)
+	client := testBasicAuthClient(t)
+	client.Config.Endpoints.API = server.URL
+
+	return client, listing, server.Close
+}
+
+func testIDs(docs []*Document) string {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return fmt.Sprint(ids)
+}
+
+func Test_ListAll(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		client, listing, stop := testListingClient(t, 7, nil)
+
+		docs, resp := client.ListAll(context.Background(), ListOptions{Limit: 3, UserIdentifier: "user1", Prefetch: prefetch})
+		stop()
+
+		assertEqual(t, resp.Error, nil, "")
+		assertEqual(t, testIDs(docs), "[7 6 5 4 3 2 1]", "")
+		assertEqual(t, listing.requests, 3, "")
+		assertEqual(t, docs[0].Owner, "user1", "")
+	}
+
+	client, _, stop := testListingClient(t, 0, nil)
+	defer stop()
+
+	docs, resp := client.ListAll(context.Background(), ListOptions{UserIdentifier: "user1"})
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, len(docs), 0, "")
+}
+
+func Test_ListAllChanges(t *testing.T) {
+	// documents uploaded between pages are skipped
+	client, _, stop := testListingClient(t, 7, func(l *testListing, request int) {
+		if request == 2 {
+			l.ids = append([]string{"9", "8"}, l.ids...)
+		}
+	})
+	docs, resp := client.ListAll(context.Background(), ListOptions{Limit: 3, UserIdentifier: "user1"})
+	stop()
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, testIDs(docs), "[7 6 5 4 3 2 1]", "")
+
+	// documents deleted between pages do not hide others, the walk moves
+	// back and returns the skipped documents later
+	client, _, stop = testListingClient(t, 7, func(l *testListing, request int) {
+		if request == 2 {
+			l.ids = append(l.ids[:1], l.ids[3:]...)
+		}
+	})
+	docs, resp = client.ListAll(context.Background(), ListOptions{Limit: 3, UserIdentifier: "user1"})
+	stop()
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, testIDs(docs), "[7 6 5 2 1 4 3]", "")
+}
+
+func Test_DocumentIteratorCancel(t *testing.T) {
+	client, listing, stop := testListingClient(t, 7, nil)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it := client.Iterate(ctx, ListOptions{Limit: 3, UserIdentifier: "user1", Prefetch: true})
+
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Document().ID)
+		if len(ids) == 3 {
+			cancel()
+		}
+	}
+
+	assertEqual(t, fmt.Sprint(ids), "[7 6 5]", "")
+	assertEqual(t, it.Response().Error, context.Canceled, "")
+	assertEqual(t, it.Document() == nil, true, "")
+
+	listing.Lock()
+	defer listing.Unlock()
+	assertEqual(t, listing.requests <= 2, true, "")
+
+	// failed requests end the walk
+	client = testBasicAuthClient(t)
+	client.Config.Endpoints.API = testHTTPServer.URL + "/missing"
+	it = client.Iterate(context.Background(), ListOptions{UserIdentifier: "user1"})
+	assertEqual(t, it.Next(), false, "")
+	assertEqual(t, it.Response().Message, ErrDocumentList, "")
+}
+
+func Test_ListAllFilters(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		client, _, stop := testListingClient(t, 7, nil)
+
+		// pages without matches are skipped
+		docs, resp := client.ListAll(context.Background(), ListOptions{Limit: 2, UserIdentifier: "user1", Prefetch: prefetch, Name: "*.pdf", CreatedBefore: time.Unix(5, 0)})
+
+		assertEqual(t, resp.Error, nil, "")
+		assertEqual(t, testIDs(docs), "[4 2]", "")
+
+		docs, resp = client.ListAll(context.Background(), ListOptions{Limit: 2, UserIdentifier: "user1", Prefetch: prefetch, SortBy: SortByName, Descending: true, CreatedAfter: time.Unix(3, 0)})
+		stop()
+
+		assertEqual(t, resp.Error, nil, "")
+		assertEqual(t, testIDs(docs), "[7 6 5 4 3]", "")
+	}
+
+	client, listing, stop := testListingClient(t, 7, nil)
+	defer stop()
+
+	_, resp := client.ListAll(context.Background(), ListOptions{UserIdentifier: "user1", SortBy: "size"})
+	assertEqual(t, resp.Message, ErrListOptions, "")
+	assertEqual(t, listing.requests, 0, "")
+}
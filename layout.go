@@ -4,6 +4,16 @@ type Layout struct {
 	Pages []PageLayout
 }
 
+// Page returns the layout of the page with the given number or nil
+func (l *Layout) Page(number int) *PageLayout {
+	for i := range l.Pages {
+		if l.Pages[i].Number == number {
+			return &l.Pages[i]
+		}
+	}
+	return nil
+}
+
 type PageLayout struct {
 	Number    int
 	SizeX     float64
@@ -23,6 +33,26 @@ type PageCoordinates struct {
 	L float64
 }
 
+// Right returns the right edge
+func (c PageCoordinates) Right() float64 {
+	return c.L + c.W
+}
+
+// Bottom returns the bottom edge
+func (c PageCoordinates) Bottom() float64 {
+	return c.T + c.H
+}
+
+// Intersects reports whether both rectangles overlap
+func (c PageCoordinates) Intersects(other PageCoordinates) bool {
+	return c.L < other.Right() && other.L < c.Right() && c.T < other.Bottom() && other.T < c.Bottom()
+}
+
+// Contains reports whether other lies completely inside c
+func (c PageCoordinates) Contains(other PageCoordinates) bool {
+	return other.L >= c.L && other.T >= c.T && other.Right() <= c.Right() && other.Bottom() <= c.Bottom()
+}
+
 type Paragraph struct {
 	PageCoordinates
 	Lines []Line
@@ -30,7 +60,7 @@ type Paragraph struct {
 
 type Line struct {
 	PageCoordinates
-	Words []Word
+	Words []Word `json:"wds"`
 }
 
 type Word struct {
@@ -0,0 +1,26 @@
+package giniapi
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_LayoutWords(t *testing.T) {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Layout: testHTTPServer.URL + "/test/layout",
+		},
+	}
+
+	layout, resp := doc.GetLayout(context.Background())
+
+	assertEqual(t, resp.Error, nil, "")
+
+	// words of a line are sent as "wds"
+	words := layout.Pages[0].TextZones[0].Paragraphs[0].Lines[0].Words
+	assertEqual(t, len(words), 3, "")
+	assertEqual(t, words[1].Text, "Vorgangsnummer", "")
+	assertEqual(t, words[1].L, 74.86, "")
+	assertEqual(t, words[1].Fontsize, 9.9, "")
+}
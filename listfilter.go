@@ -0,0 +1,102 @@
+package giniapi
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// sort orders of ListOptions
+const (
+	SortByCreationDate = "creationDate"
+	SortByName         = "name"
+	SortByPageCount    = "pageCount"
+)
+
+// validate checks the name pattern and sort order
+func (o ListOptions) validate() error {
+	if _, err := path.Match(o.Name, ""); err != nil {
+		return fmt.Errorf("%s: name pattern %q: %s", ErrListOptions, o.Name, err)
+	}
+
+	switch o.SortBy {
+	case "", SortByCreationDate, SortByName, SortByPageCount:
+	default:
+		return fmt.Errorf("%s: unknown sort order %q", ErrListOptions, o.SortBy)
+	}
+
+	return nil
+}
+
+// matches reports whether the document passes all filters
+func (o ListOptions) matches(d *Document) bool {
+	// oneOf reports whether any of n values matches or n is 0
+	oneOf := func(n int, match func(i int) bool) bool {
+		for i := 0; i < n; i++ {
+			if match(i) {
+				return true
+			}
+		}
+		return n == 0
+	}
+
+	if !oneOf(len(o.Progress), func(i int) bool { return o.Progress[i] == d.Progress }) ||
+		!oneOf(len(o.SourceClassification), func(i int) bool { return o.SourceClassification[i] == d.SourceClassification }) ||
+		!oneOf(len(o.Origin), func(i int) bool { return o.Origin[i] == d.Origin }) {
+		return false
+	}
+
+	// documents of unknown age are never within a date range
+	if (!o.CreatedAfter.IsZero() || !o.CreatedBefore.IsZero()) && d.CreationDate.IsZero() {
+		return false
+	}
+	if !o.CreatedAfter.IsZero() && d.CreationDate.Before(o.CreatedAfter) {
+		return false
+	}
+	if !o.CreatedBefore.IsZero() && !d.CreationDate.Before(o.CreatedBefore) {
+		return false
+	}
+
+	if o.Name != "" {
+		if ok, _ := path.Match(o.Name, d.Name); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filter returns the documents passing all filters
+func (o ListOptions) filter(docs []*Document) []*Document {
+	filtered := docs[:0]
+	for _, d := range docs {
+		if o.matches(d) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// sort orders the documents by the sort order of the options, documents
+// with equal keys keep their order
+func (o ListOptions) sort(docs []*Document) {
+	var less func(a, b *Document) bool
+
+	switch o.SortBy {
+	case SortByCreationDate:
+		less = func(a, b *Document) bool { return a.CreationDate.Before(b.CreationDate) }
+	case SortByName:
+		less = func(a, b *Document) bool { return a.Name < b.Name }
+	case SortByPageCount:
+		less = func(a, b *Document) bool { return a.PageCount < b.PageCount }
+	default:
+		return
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		if o.Descending {
+			return less(docs[j], docs[i])
+		}
+		return less(docs[i], docs[j])
+	})
+}
@@ -0,0 +1,370 @@
+package qrcode
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Segment modes of the bit stream
+const (
+	modeTerminator   = 0
+	modeNumeric      = 1
+	modeAlphanumeric = 2
+	modeStructured   = 3
+	modeByte         = 4
+	modeFNC1First    = 5
+	modeECI          = 7
+	modeKanji        = 8
+	modeFNC1Second   = 9
+)
+
+const alphanumericChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// decodeModules decodes a sampled symbol of the given size. black reports
+// the color of the module at column x and row y.
+func decodeModules(size int, black func(x, y int) bool) (*Code, []byte, error) {
+	if size < 21 || size > 177 || (size-17)%4 != 0 {
+		return nil, nil, errors.New(ErrInvalidCode)
+	}
+
+	version := (size - 17) / 4
+	if version >= 7 {
+		v, ok := readVersion(size, black)
+		if !ok || v != version {
+			return nil, nil, errors.New(ErrInvalidCode)
+		}
+	}
+
+	level, mask, ok := readFormat(size, black)
+	if !ok {
+		return nil, nil, errors.New(ErrInvalidCode)
+	}
+
+	m := newMatrix(version)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if !m.isFunction(x, y) {
+				m.set(x, y, black(x, y))
+			}
+		}
+	}
+	m.applyMask(mask)
+
+	info := blockTable[version][level]
+	data, err := deinterleave(m.readData(), info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := parseSegments(data, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.applyMask(mask)
+	m.drawFormat(level, mask)
+
+	code := &Code{
+		Version: version,
+		Level:   level,
+		Mask:    mask,
+		Size:    size,
+		modules: m.modules,
+	}
+
+	return code, content, nil
+}
+
+// readFormat reads both copies of the format information and returns the
+// closest valid level and mask
+func readFormat(size int, black func(x, y int) bool) (Level, int, bool) {
+	var first, second int
+
+	read := func(bits *int, x, y int) {
+		*bits <<= 1
+		if black(x, y) {
+			*bits |= 1
+		}
+	}
+
+	// bits are read from 14 down to 0
+	for i := 14; i >= 9; i-- {
+		read(&first, 14-i, 8)
+	}
+	read(&first, 7, 8)
+	read(&first, 8, 8)
+	read(&first, 8, 7)
+	for i := 5; i >= 0; i-- {
+		read(&first, 8, i)
+	}
+
+	for i := 14; i >= 8; i-- {
+		read(&second, 8, size-15+i)
+	}
+	for i := 7; i >= 0; i-- {
+		read(&second, size-1-i, 8)
+	}
+
+	bestDistance, bestLevel, bestMask := 16, Low, 0
+	for level := Low; level <= High; level++ {
+		for mask := 0; mask < 8; mask++ {
+			format := formatInformation(level, mask)
+			for _, candidate := range []int{first, second} {
+				if d := bits.OnesCount(uint(format ^ candidate)); d < bestDistance {
+					bestDistance, bestLevel, bestMask = d, level, mask
+				}
+			}
+		}
+	}
+
+	return bestLevel, bestMask, bestDistance <= 3
+}
+
+// readVersion reads both copies of the version information (version 7+)
+func readVersion(size int, black func(x, y int) bool) (int, bool) {
+	var first, second int
+	for i := 17; i >= 0; i-- {
+		a, b := size-11+i%3, i/3
+		first <<= 1
+		if black(a, b) {
+			first |= 1
+		}
+		second <<= 1
+		if black(b, a) {
+			second |= 1
+		}
+	}
+
+	bestDistance, bestVersion := 19, 0
+	for version := 7; version <= 40; version++ {
+		info := versionInformation(version)
+		for _, candidate := range []int{first, second} {
+			if d := bits.OnesCount(uint(info ^ candidate)); d < bestDistance {
+				bestDistance, bestVersion = d, version
+			}
+		}
+	}
+
+	return bestVersion, bestDistance <= 3
+}
+
+// deinterleave splits the codewords into blocks, corrects errors and
+// returns the data codewords
+func deinterleave(codewords []byte, info blockInfo) ([]byte, error) {
+	blocks := make([][]byte, info.blocks())
+	for i := range blocks {
+		n := info.shortData
+		if i >= info.shortBlocks {
+			n = info.longData
+		}
+		blocks[i] = make([]byte, 0, n+info.ecCodewords)
+	}
+
+	total := info.dataCodewords() + info.blocks()*info.ecCodewords
+	if len(codewords) < total {
+		return nil, errors.New(ErrInvalidCode)
+	}
+
+	maxData := info.shortData
+	if info.longBlocks > 0 {
+		maxData = info.longData
+	}
+
+	offset := 0
+	for i := 0; i < maxData; i++ {
+		for b := range blocks {
+			if i < info.shortData || b >= info.shortBlocks {
+				blocks[b] = append(blocks[b], codewords[offset])
+				offset++
+			}
+		}
+	}
+	for i := 0; i < info.ecCodewords; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codewords[offset])
+			offset++
+		}
+	}
+
+	var data []byte
+	for _, block := range blocks {
+		if _, err := rsCorrect(block, info.ecCodewords); err != nil {
+			return nil, err
+		}
+		data = append(data, block[:len(block)-info.ecCodewords]...)
+	}
+
+	return data, nil
+}
+
+// bitReader reads bits most significant first
+type bitReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *bitReader) available() int {
+	return len(r.data)*8 - r.offset
+}
+
+func (r *bitReader) read(n int) (int, error) {
+	if n > r.available() {
+		return 0, errors.New(ErrInvalidCode)
+	}
+	value := 0
+	for i := 0; i < n; i++ {
+		value <<= 1
+		if r.data[r.offset/8]>>uint(7-r.offset%8)&1 == 1 {
+			value |= 1
+		}
+		r.offset++
+	}
+	return value, nil
+}
+
+// segmentCountBits returns the length of the character count indicator
+func segmentCountBits(mode, version int) int {
+	index := 0
+	if version >= 27 {
+		index = 2
+	} else if version >= 10 {
+		index = 1
+	}
+
+	switch mode {
+	case modeNumeric:
+		return [...]int{10, 12, 14}[index]
+	case modeAlphanumeric:
+		return [...]int{9, 11, 13}[index]
+	case modeByte:
+		return [...]int{8, 16, 16}[index]
+	default:
+		return [...]int{8, 10, 12}[index]
+	}
+}
+
+// parseSegments decodes the data codewords into the raw content. Byte and
+// kanji segments are returned as they are, ECI designators are skipped.
+func parseSegments(data []byte, version int) ([]byte, error) {
+	r := &bitReader{data: data}
+	var content []byte
+
+	for r.available() >= 4 {
+		mode, _ := r.read(4)
+
+		switch mode {
+		case modeTerminator:
+			return content, nil
+		case modeFNC1First:
+		case modeFNC1Second:
+			if _, err := r.read(8); err != nil {
+				return nil, err
+			}
+		case modeStructured:
+			if _, err := r.read(16); err != nil {
+				return nil, err
+			}
+		case modeECI:
+			first, err := r.read(8)
+			if err != nil {
+				return nil, err
+			}
+			if first&0x80 != 0 {
+				extra := 8
+				if first&0xc0 == 0xc0 {
+					extra = 16
+				}
+				if _, err := r.read(extra); err != nil {
+					return nil, err
+				}
+			}
+		case modeNumeric, modeAlphanumeric, modeByte, modeKanji:
+			count, err := r.read(segmentCountBits(mode, version))
+			if err != nil {
+				return nil, err
+			}
+			segment, err := readSegment(r, mode, count)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, segment...)
+		default:
+			return nil, errors.New(ErrInvalidCode)
+		}
+	}
+
+	return content, nil
+}
+
+// readSegment reads count characters of a segment
+func readSegment(r *bitReader, mode, count int) ([]byte, error) {
+	var segment []byte
+
+	switch mode {
+	case modeNumeric:
+		for count > 0 {
+			digits := 3
+			if count < 3 {
+				digits = count
+			}
+			value, err := r.read(digits*3 + 1)
+			if err != nil {
+				return nil, err
+			}
+			for i, div := 0, pow10(digits-1); i < digits; i, div = i+1, div/10 {
+				segment = append(segment, byte('0'+value/div%10))
+			}
+			count -= digits
+		}
+	case modeAlphanumeric:
+		for count > 0 {
+			if count == 1 {
+				value, err := r.read(6)
+				if err != nil || value >= 45 {
+					return nil, errors.New(ErrInvalidCode)
+				}
+				segment = append(segment, alphanumericChars[value])
+				break
+			}
+			value, err := r.read(11)
+			if err != nil || value >= 45*45 {
+				return nil, errors.New(ErrInvalidCode)
+			}
+			segment = append(segment, alphanumericChars[value/45], alphanumericChars[value%45])
+			count -= 2
+		}
+	case modeByte:
+		for i := 0; i < count; i++ {
+			value, err := r.read(8)
+			if err != nil {
+				return nil, err
+			}
+			segment = append(segment, byte(value))
+		}
+	case modeKanji:
+		// returned as Shift JIS
+		for i := 0; i < count; i++ {
+			value, err := r.read(13)
+			if err != nil {
+				return nil, err
+			}
+			assembled := (value/0xc0)<<8 | value%0xc0
+			if assembled < 0x1f00 {
+				assembled += 0x8140
+			} else {
+				assembled += 0xc140
+			}
+			segment = append(segment, byte(assembled>>8), byte(assembled))
+		}
+	}
+
+	return segment, nil
+}
+
+func pow10(n int) int {
+	result := 1
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// rotate renders img rotated by angle degrees onto a white canvas
+func rotate(img image.Image, angle float64) image.Image {
+	b := img.Bounds()
+	side := int(float64(b.Dx()+b.Dy()) * 1.2)
+	dst := image.NewGray(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(side)/2, float64(side)/2
+	sx, sy := float64(b.Dx())/2, float64(b.Dy())/2
+
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			ox := int(math.Floor(cos*dx+sin*dy+sx)) + b.Min.X
+			oy := int(math.Floor(-sin*dx+cos*dy+sy)) + b.Min.Y
+			if image.Pt(ox, oy).In(b) {
+				dst.Set(x, y, img.At(ox, oy))
+			}
+		}
+	}
+	return dst
+}
+
+func Test_DecodeRoundTrip(t *testing.T) {
+	for _, n := range []int{5, 40, 120, 331, 700} {
+		data := make([]byte, n)
+		rand.New(rand.NewSource(int64(n))).Read(data)
+
+		for level := Low; level <= High; level++ {
+			code, err := Encode(data, level)
+			if err != nil {
+				continue
+			}
+
+			results, err := Decode(code.Image(3))
+			if err != nil {
+				t.Errorf("%d bytes, version %d-%s: %s", n, code.Version, level, err)
+				continue
+			}
+			if !bytes.Equal(results[0].Data, data) || results[0].Version != code.Version {
+				t.Errorf("%d bytes, version %d-%s: content mismatch", n, code.Version, level)
+			}
+		}
+	}
+}
+
+func Test_DecodeRotated(t *testing.T) {
+	data := []byte("BCD\n002\n1\nSCT\n\nGini GmbH\nDE02120300000000202051\nEUR24.99\n\n\nRechnung 4711")
+	code, _ := Encode(data, Medium)
+
+	for _, angle := range []float64{0, 7, 30, 90, 135, 180, 263} {
+		results, err := Decode(rotate(code.Image(5), angle))
+		if err != nil {
+			t.Errorf("%.0f degrees: %s", angle, err)
+			continue
+		}
+		if !bytes.Equal(results[0].Data, data) {
+			t.Errorf("%.0f degrees: content mismatch", angle)
+		}
+	}
+}
+
+func Test_DecodeOnPage(t *testing.T) {
+	page := image.NewRGBA(image.Rect(0, 0, 1240, 1754))
+	draw.Draw(page, page.Bounds(), image.NewUniform(color.RGBA{240, 238, 230, 255}), image.Point{}, draw.Src)
+
+	// fake text lines
+	r := rand.New(rand.NewSource(1))
+	for y := 100; y < 1600; y += 28 {
+		for x := 80; x < 1100; x += 6 + r.Intn(10) {
+			draw.Draw(page, image.Rect(x, y, x+3+r.Intn(4), y+14), image.Black, image.Point{}, draw.Src)
+		}
+	}
+
+	first, _ := Encode([]byte("first code"), Medium)
+	second, _ := Encode([]byte("bank://singlepaymentsepa?name=Gini&iban=DE02120300000000202051"), Quartile)
+
+	firstImg, secondImg := first.Image(3), second.Image(4)
+	draw.Draw(page, firstImg.Bounds().Add(image.Pt(900, 1400)), firstImg, image.Point{}, draw.Src)
+	draw.Draw(page, secondImg.Bounds().Add(image.Pt(100, 1300)), secondImg, image.Point{}, draw.Src)
+
+	results, err := Decode(page)
+	if err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 codes, got %d", len(results))
+	}
+
+	found := map[string]image.Rectangle{}
+	for _, result := range results {
+		found[string(result.Data)] = result.Bounds
+	}
+
+	bounds, ok := found["first code"]
+	if !ok || !bounds.In(firstImg.Bounds().Add(image.Pt(900, 1400)).Inset(-3)) {
+		t.Errorf("first code missing or misplaced: %v", bounds)
+	}
+	if _, ok := found[string("bank://singlepaymentsepa?name=Gini&iban=DE02120300000000202051")]; !ok {
+		t.Errorf("second code missing")
+	}
+}
+
+func Test_DecodeNotFound(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 200))
+	if _, err := Decode(img); err == nil || err.Error() != ErrNotFound {
+		t.Errorf("Expected %q, got %v", ErrNotFound, err)
+	}
+}
+
+func Test_rsCorrect(t *testing.T) {
+	data := []byte("hello reed solomon")
+	block := append(append([]byte{}, data...), rsEncode(data, 10)...)
+
+	corrupted := append([]byte{}, block...)
+	corrupted[0] ^= 0xff
+	corrupted[7] ^= 0x12
+	corrupted[20] ^= 0x01
+	corrupted[24] ^= 0x80
+	corrupted[len(corrupted)-1] ^= 0x33
+
+	n, err := rsCorrect(corrupted, 10)
+	assertEqual(t, err, nil, "")
+	assertEqual(t, n, 5, "")
+	assertEqual(t, string(corrupted), string(block), "")
+
+	for i := 0; i < 6; i++ {
+		corrupted[i*3] ^= 0x55
+	}
+	_, err = rsCorrect(corrupted, 10)
+	assertNotEqual(t, err, nil, "")
+}
+
+func Test_parseSegments(t *testing.T) {
+	var bits bitBuffer
+	// numeric "01234567"
+	bits.append(modeNumeric, 4)
+	bits.append(8, 10)
+	bits.append(12, 10)
+	bits.append(345, 10)
+	bits.append(67, 7)
+	// alphanumeric "AC-42"
+	bits.append(modeAlphanumeric, 4)
+	bits.append(5, 9)
+	bits.append(10*45+12, 11)
+	bits.append(41*45+4, 11)
+	bits.append(2, 6)
+	bits.append(modeTerminator, 4)
+
+	content, err := parseSegments(bits.bytes(), 1)
+	assertEqual(t, err, nil, "")
+	assertEqual(t, string(content), "01234567AC-42", "")
+}
@@ -0,0 +1,712 @@
+package qrcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// ErrNotFound is returned if an image contains no readable QR code
+const ErrNotFound = "no QR code found"
+
+// Result is a QR code found in an image
+type Result struct {
+	*Code
+	// Data holds the raw content. Byte segments are not converted, their
+	// character set is defined by the application (UTF-8 for GiroCodes).
+	Data []byte
+	// Bounds of the symbol in image coordinates
+	Bounds image.Rectangle
+}
+
+// Decode finds and decodes all QR codes in img. Codes must not be mirrored,
+// but may be rotated and slightly distorted.
+func Decode(img image.Image) ([]Result, error) {
+	gray := grayscale(img)
+
+	var results []Result
+	for _, binary := range []*bitmap{hybridBinarize(gray), globalBinarize(gray)} {
+		results = decodeBitmap(binary)
+		if len(results) > 0 {
+			return results, nil
+		}
+	}
+
+	return nil, errors.New(ErrNotFound)
+}
+
+// bitmap is a binarized image
+type bitmap struct {
+	width, height int
+	black         []bool
+}
+
+func (b *bitmap) get(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.width || y >= b.height {
+		return false
+	}
+	return b.black[y*b.width+x]
+}
+
+// grayImage holds the luminance of an image
+type grayImage struct {
+	width, height int
+	pix           []uint8
+}
+
+// grayscale converts img to luminance values
+func grayscale(img image.Image) *grayImage {
+	bounds := img.Bounds()
+	g := &grayImage{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		pix:    make([]uint8, bounds.Dx()*bounds.Dy()),
+	}
+
+	switch src := img.(type) {
+	case *image.Gray:
+		for y := 0; y < g.height; y++ {
+			copy(g.pix[y*g.width:], src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y+y):][:g.width])
+		}
+	case *image.YCbCr:
+		for y := 0; y < g.height; y++ {
+			for x := 0; x < g.width; x++ {
+				g.pix[y*g.width+x] = src.Y[src.YOffset(bounds.Min.X+x, bounds.Min.Y+y)]
+			}
+		}
+	default:
+		for y := 0; y < g.height; y++ {
+			for x := 0; x < g.width; x++ {
+				g.pix[y*g.width+x] = color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y
+			}
+		}
+	}
+
+	return g
+}
+
+// globalBinarize thresholds the image with Otsu's method
+func globalBinarize(g *grayImage) *bitmap {
+	var histogram [256]int
+	for _, p := range g.pix {
+		histogram[p]++
+	}
+
+	total := len(g.pix)
+	sum := 0
+	for i, count := range histogram {
+		sum += i * count
+	}
+
+	threshold, best := 128, -1.0
+	sumBackground, weightBackground := 0, 0
+	for i, count := range histogram {
+		weightBackground += count
+		if weightBackground == 0 {
+			continue
+		}
+		weightForeground := total - weightBackground
+		if weightForeground == 0 {
+			break
+		}
+		sumBackground += i * count
+		meanBackground := float64(sumBackground) / float64(weightBackground)
+		meanForeground := float64(sum-sumBackground) / float64(weightForeground)
+		variance := float64(weightBackground) * float64(weightForeground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if variance > best {
+			best, threshold = variance, i
+		}
+	}
+
+	b := &bitmap{width: g.width, height: g.height, black: make([]bool, len(g.pix))}
+	for i, p := range g.pix {
+		b.black[i] = int(p) <= threshold
+	}
+	return b
+}
+
+// hybridBinarize thresholds blocks of 8x8 pixels against the average of
+// their 5x5 block neighbourhood, which copes with shadows and gradients
+func hybridBinarize(g *grayImage) *bitmap {
+	const blockSize = 8
+	const minDynamicRange = 24
+
+	blocksX := (g.width + blockSize - 1) / blockSize
+	blocksY := (g.height + blockSize - 1) / blockSize
+	if blocksX < 5 || blocksY < 5 {
+		return globalBinarize(g)
+	}
+
+	averages := make([]int, blocksX*blocksY)
+	for by := 0; by < blocksY; by++ {
+		for bx := 0; bx < blocksX; bx++ {
+			sum, count, min, max := 0, 0, 255, 0
+			for y := by * blockSize; y < (by+1)*blockSize && y < g.height; y++ {
+				for x := bx * blockSize; x < (bx+1)*blockSize && x < g.width; x++ {
+					p := int(g.pix[y*g.width+x])
+					sum += p
+					count++
+					if p < min {
+						min = p
+					}
+					if p > max {
+						max = p
+					}
+				}
+			}
+
+			average := sum / count
+			if max-min <= minDynamicRange {
+				// flat block: assume it is background unless the neighbours
+				// indicate a dark area (e.g. the center of a finder pattern)
+				average = min / 2
+				if by > 0 && bx > 0 {
+					neighbours := (averages[(by-1)*blocksX+bx] + 2*averages[by*blocksX+bx-1] + averages[(by-1)*blocksX+bx-1]) / 4
+					if min < neighbours {
+						average = neighbours
+					}
+				}
+			}
+			averages[by*blocksX+bx] = average
+		}
+	}
+
+	b := &bitmap{width: g.width, height: g.height, black: make([]bool, len(g.pix))}
+	for by := 0; by < blocksY; by++ {
+		for bx := 0; bx < blocksX; bx++ {
+			cx := clamp(bx, 2, blocksX-3)
+			cy := clamp(by, 2, blocksY-3)
+			sum := 0
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					sum += averages[(cy+dy)*blocksX+cx+dx]
+				}
+			}
+			threshold := sum / 25
+
+			for y := by * blockSize; y < (by+1)*blockSize && y < g.height; y++ {
+				for x := bx * blockSize; x < (bx+1)*blockSize && x < g.width; x++ {
+					b.black[y*g.width+x] = int(g.pix[y*g.width+x]) <= threshold
+				}
+			}
+		}
+	}
+
+	return b
+}
+
+// point is a position in image coordinates
+type point struct {
+	x, y float64
+}
+
+func distance(a, b point) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// finder is a finder pattern candidate
+type finder struct {
+	point
+	moduleSize float64
+	count      int
+}
+
+// decodeBitmap locates finder patterns and tries to decode every plausible
+// combination of three of them
+func decodeBitmap(b *bitmap) []Result {
+	finders := findFinders(b)
+
+	type triple struct {
+		a, b, c int
+		score   float64
+	}
+
+	var triples []triple
+	for i := range finders {
+		for j := i + 1; j < len(finders); j++ {
+			for k := j + 1; k < len(finders); k++ {
+				if score, ok := tripleScore(finders[i], finders[j], finders[k]); ok {
+					triples = append(triples, triple{i, j, k, score})
+				}
+			}
+		}
+	}
+
+	sort.Slice(triples, func(i, j int) bool { return triples[i].score < triples[j].score })
+
+	used := make([]bool, len(finders))
+	var results []Result
+
+	for _, t := range triples {
+		if used[t.a] || used[t.b] || used[t.c] {
+			continue
+		}
+		if result, ok := decodeTriple(b, finders[t.a], finders[t.b], finders[t.c]); ok {
+			used[t.a], used[t.b], used[t.c] = true, true, true
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// tripleScore rates how well three finder patterns form the corners of a
+// symbol. Lower scores are better.
+func tripleScore(a, b, c finder) (float64, bool) {
+	sizes := []float64{a.moduleSize, b.moduleSize, c.moduleSize}
+	sort.Float64s(sizes)
+	if sizes[2] > sizes[0]*1.5 {
+		return 0, false
+	}
+
+	ab, ac, bc := distance(a.point, b.point), distance(a.point, c.point), distance(b.point, c.point)
+	sides := []float64{ab, ac, bc}
+	sort.Float64s(sides)
+
+	// two legs of similar length and a hypotenuse
+	legRatio := sides[1] / sides[0]
+	hypotenuse := math.Hypot(sides[0], sides[1])
+	if legRatio > 1.4 || math.Abs(sides[2]-hypotenuse)/hypotenuse > 0.15 {
+		return 0, false
+	}
+
+	// distance between the finders in modules must fit a symbol
+	modules := sides[0] / sizes[1]
+	if modules < 10 || modules > 180 {
+		return 0, false
+	}
+
+	return math.Abs(legRatio-1) + math.Abs(sides[2]-hypotenuse)/hypotenuse, true
+}
+
+// decodeTriple orders three finder patterns and samples the symbol between
+// them with several dimension and alignment assumptions
+func decodeTriple(b *bitmap, p1, p2, p3 finder) (Result, bool) {
+	// the top left finder is opposite the longest side
+	topLeft, topRight, bottomLeft := p1, p2, p3
+	d12, d13, d23 := distance(p1.point, p2.point), distance(p1.point, p3.point), distance(p2.point, p3.point)
+	if d13 >= d12 && d13 >= d23 {
+		topLeft, topRight, bottomLeft = p2, p1, p3
+	} else if d12 >= d13 && d12 >= d23 {
+		topLeft, topRight, bottomLeft = p3, p1, p2
+	}
+
+	// in image coordinates the top right finder lies clockwise
+	cross := (topRight.x-topLeft.x)*(bottomLeft.y-topLeft.y) - (topRight.y-topLeft.y)*(bottomLeft.x-topLeft.x)
+	if cross < 0 {
+		topRight, bottomLeft = bottomLeft, topRight
+	}
+
+	// the cross checks overestimate the module size of rotated symbols, so
+	// measure the finders along the lines between them
+	moduleSize := (topLeft.moduleSize + topRight.moduleSize + bottomLeft.moduleSize) / 3
+	sum, count := 0.0, 0
+	for _, line := range [][2]point{
+		{topLeft.point, topRight.point}, {topRight.point, topLeft.point},
+		{topLeft.point, bottomLeft.point}, {bottomLeft.point, topLeft.point},
+	} {
+		if size, ok := moduleSizeAlong(b, line[0], line[1]); ok {
+			sum += size
+			count++
+		}
+	}
+	if count > 0 {
+		moduleSize = sum / float64(count)
+	}
+
+	estimate := (distance(topLeft.point, topRight.point)+distance(topLeft.point, bottomLeft.point))/(2*moduleSize) + 7
+
+	base := int(math.Round((estimate-17)/4))*4 + 17
+	for _, size := range []int{base, base - 4, base + 4} {
+		if size < 21 || size > 177 {
+			continue
+		}
+
+		transforms := []*perspective{}
+		if size > 21 {
+			if alignment, ok := findAlignment(b, topLeft.point, topRight.point, bottomLeft.point, size, moduleSize); ok {
+				transforms = append(transforms, quadToQuad(
+					[4]point{{3.5, 3.5}, {float64(size) - 3.5, 3.5}, {float64(size) - 6.5, float64(size) - 6.5}, {3.5, float64(size) - 3.5}},
+					[4]point{topLeft.point, topRight.point, alignment, bottomLeft.point},
+				))
+			}
+		}
+
+		bottomRight := point{topRight.x + bottomLeft.x - topLeft.x, topRight.y + bottomLeft.y - topLeft.y}
+		transforms = append(transforms, quadToQuad(
+			[4]point{{3.5, 3.5}, {float64(size) - 3.5, 3.5}, {float64(size) - 3.5, float64(size) - 3.5}, {3.5, float64(size) - 3.5}},
+			[4]point{topLeft.point, topRight.point, bottomRight, bottomLeft.point},
+		))
+
+		for _, t := range transforms {
+			black := func(x, y int) bool {
+				p := t.apply(point{float64(x) + 0.5, float64(y) + 0.5})
+				return b.get(int(p.x), int(p.y))
+			}
+
+			code, data, err := decodeModules(size, black)
+			if err != nil {
+				continue
+			}
+
+			return Result{Code: code, Data: data, Bounds: t.bounds(size)}, true
+		}
+	}
+
+	return Result{}, false
+}
+
+// moduleSizeAlong measures the finder pattern centered at from along the line
+// towards to. The pattern spans 7 modules.
+func moduleSizeAlong(b *bitmap, from, to point) (float64, bool) {
+	length := distance(from, to)
+	if length == 0 {
+		return 0, false
+	}
+	ux, uy := (to.x-from.x)/length, (to.y-from.y)/length
+
+	// distance from the center to the outer edge, which is 3.5 modules
+	edge := func(dx, dy float64) (float64, bool) {
+		state := 0
+		for i := 0.0; i < length/2; i++ {
+			x, y := int(from.x+dx*i), int(from.y+dy*i)
+			if !inside(b, x, y) {
+				return 0, false
+			}
+			if b.get(x, y) != (state%2 == 0) {
+				state++
+				if state == 3 {
+					return i, true
+				}
+			}
+		}
+		return 0, false
+	}
+
+	forward, ok := edge(ux, uy)
+	if !ok {
+		return 0, false
+	}
+	backward, ok := edge(-ux, -uy)
+	if !ok {
+		return 0, false
+	}
+
+	return (forward + backward) / 7, true
+}
+
+// findAlignment searches the bottom right alignment pattern around its
+// expected position by matching a 5x5 module template
+func findAlignment(b *bitmap, topLeft, topRight, bottomLeft point, size int, moduleSize float64) (point, bool) {
+	span := float64(size - 7)
+	ux := point{(topRight.x - topLeft.x) / span, (topRight.y - topLeft.y) / span}
+	uy := point{(bottomLeft.x - topLeft.x) / span, (bottomLeft.y - topLeft.y) / span}
+
+	// alignment center is 3 modules closer to the top left than the corner
+	offset := span - 3
+	expected := point{topLeft.x + (ux.x+uy.x)*offset, topLeft.y + (ux.y+uy.y)*offset}
+
+	radius := int(math.Ceil(moduleSize * 4))
+	best, bestScore, bestDistance := point{}, 0, math.MaxFloat64
+
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			center := point{expected.x + float64(dx), expected.y + float64(dy)}
+
+			score := 0
+			for my := -2; my <= 2; my++ {
+				for mx := -2; mx <= 2; mx++ {
+					want := maxInt(abs(mx), abs(my)) != 1
+					x := center.x + ux.x*float64(mx) + uy.x*float64(my)
+					y := center.y + ux.y*float64(mx) + uy.y*float64(my)
+					if b.get(int(x), int(y)) == want {
+						score++
+					}
+				}
+			}
+
+			d := math.Hypot(float64(dx), float64(dy))
+			if score > bestScore || (score == bestScore && d < bestDistance) {
+				best, bestScore, bestDistance = center, score, d
+			}
+		}
+	}
+
+	return best, bestScore >= 24
+}
+
+// findFinders scans the bitmap for the 1:1:3:1:1 pattern of finder patterns
+func findFinders(b *bitmap) []finder {
+	var finders []finder
+
+	for y := 0; y < b.height; y++ {
+		var counts [5]int
+		state := 0
+
+		check := func(end int) {
+			if !finderRatio(counts) {
+				return
+			}
+			if f, ok := confirmFinder(b, counts, end, y); ok {
+				finders = mergeFinder(finders, f)
+			}
+		}
+
+		for x := 0; x < b.width; x++ {
+			if b.get(x, y) {
+				if state%2 == 1 {
+					state++
+				}
+				counts[state]++
+				continue
+			}
+
+			switch {
+			case state == 0 && counts[0] == 0:
+				// skip leading white
+			case state%2 == 1:
+				counts[state]++
+			case state == 4:
+				check(x)
+				counts = [5]int{counts[2], counts[3], counts[4], 1, 0}
+				state = 3
+			default:
+				state++
+				counts[state]++
+			}
+		}
+
+		if state == 4 {
+			check(b.width)
+		}
+	}
+
+	// drop candidates seen on a single row only, unless nothing else is left
+	var confirmed []finder
+	for _, f := range finders {
+		if f.count > 1 {
+			confirmed = append(confirmed, f)
+		}
+	}
+	if len(confirmed) >= 3 {
+		return confirmed
+	}
+
+	return finders
+}
+
+// finderRatio checks the 1:1:3:1:1 ratio of black and white runs
+func finderRatio(counts [5]int) bool {
+	total := 0
+	for _, c := range counts {
+		if c == 0 {
+			return false
+		}
+		total += c
+	}
+	if total < 7 {
+		return false
+	}
+
+	module := float64(total) / 7
+	variance := module / 1.5
+
+	return math.Abs(module-float64(counts[0])) < variance &&
+		math.Abs(module-float64(counts[1])) < variance &&
+		math.Abs(3*module-float64(counts[2])) < 3*variance &&
+		math.Abs(module-float64(counts[3])) < variance &&
+		math.Abs(module-float64(counts[4])) < variance
+}
+
+// confirmFinder cross checks a horizontal match vertically and horizontally
+// through its center and returns the refined finder
+func confirmFinder(b *bitmap, counts [5]int, end, y int) (finder, bool) {
+	total := counts[0] + counts[1] + counts[2] + counts[3] + counts[4]
+	centerX := float64(end-counts[4]-counts[3]) - float64(counts[2])/2
+
+	centerY, verticalTotal, ok := crossCheck(b, int(centerX), y, 0, 1, total)
+	if !ok {
+		return finder{}, false
+	}
+
+	centerX, horizontalTotal, ok := crossCheck(b, int(centerX), int(centerY), 1, 0, total)
+	if !ok {
+		return finder{}, false
+	}
+
+	return finder{
+		point:      point{centerX, centerY},
+		moduleSize: float64(verticalTotal+horizontalTotal) / 14,
+		count:      1,
+	}, true
+}
+
+// crossCheck measures the runs through x, y in direction dx, dy and returns
+// the center coordinate along that direction and the pattern length
+func crossCheck(b *bitmap, x, y, dx, dy, expectedTotal int) (float64, int, bool) {
+	if !b.get(x, y) {
+		return 0, 0, false
+	}
+
+	var counts [5]int
+	limit := expectedTotal
+
+	// walk backwards through the center, the white and the outer ring
+	cx, cy := x, y
+	for state := 2; state >= 0; state-- {
+		want := state != 1
+		for inside(b, cx, cy) && b.get(cx, cy) == want && counts[state] <= limit {
+			counts[state]++
+			cx, cy = cx-dx, cy-dy
+		}
+		if counts[state] == 0 || counts[state] > limit {
+			return 0, 0, false
+		}
+	}
+
+	// center pixels up to and including x, y
+	before := counts[2]
+
+	cx, cy = x+dx, y+dy
+	for state := 2; state <= 4; state++ {
+		want := state != 3
+		for inside(b, cx, cy) && b.get(cx, cy) == want && counts[state] <= limit {
+			counts[state]++
+			cx, cy = cx+dx, cy+dy
+		}
+		if counts[state] == 0 || counts[state] > limit {
+			return 0, 0, false
+		}
+	}
+
+	total := counts[0] + counts[1] + counts[2] + counts[3] + counts[4]
+	if 5*abs(total-expectedTotal) >= 2*expectedTotal || !finderRatio(counts) {
+		return 0, 0, false
+	}
+
+	position := x
+	if dy != 0 {
+		position = y
+	}
+
+	return float64(position-before+1) + float64(counts[2])/2, total, true
+}
+
+// inside reports whether x, y lies within the bitmap
+func inside(b *bitmap, x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.width && y < b.height
+}
+
+// mergeFinder adds f to the candidates or averages it with a nearby one
+func mergeFinder(finders []finder, f finder) []finder {
+	for i, existing := range finders {
+		if math.Abs(existing.x-f.x) <= existing.moduleSize*2 && math.Abs(existing.y-f.y) <= existing.moduleSize*2 &&
+			math.Abs(existing.moduleSize-f.moduleSize) <= existing.moduleSize {
+			n := float64(existing.count)
+			finders[i] = finder{
+				point:      point{(existing.x*n + f.x) / (n + 1), (existing.y*n + f.y) / (n + 1)},
+				moduleSize: (existing.moduleSize*n + f.moduleSize) / (n + 1),
+				count:      existing.count + 1,
+			}
+			return finders
+		}
+	}
+	return append(finders, f)
+}
+
+// perspective is a projective transformation between two planes
+type perspective struct {
+	a11, a12, a13, a21, a22, a23, a31, a32, a33 float64
+}
+
+// apply maps p from the source to the destination plane
+func (t *perspective) apply(p point) point {
+	denominator := t.a13*p.x + t.a23*p.y + t.a33
+	return point{
+		(t.a11*p.x + t.a21*p.y + t.a31) / denominator,
+		(t.a12*p.x + t.a22*p.y + t.a32) / denominator,
+	}
+}
+
+// bounds returns the image rectangle covered by a symbol of the given size
+func (t *perspective) bounds(size int) image.Rectangle {
+	s := float64(size)
+	var r image.Rectangle
+	for i, corner := range []point{{0, 0}, {s, 0}, {s, s}, {0, s}} {
+		p := t.apply(corner)
+		pt := image.Pt(int(math.Floor(p.x)), int(math.Floor(p.y)))
+		if i == 0 {
+			r = image.Rectangle{pt, pt}
+			continue
+		}
+		r = r.Union(image.Rectangle{pt, pt.Add(image.Pt(1, 1))})
+	}
+	return r
+}
+
+// squareToQuad maps the unit square onto the quadrilateral q
+func squareToQuad(q [4]point) *perspective {
+	dx3 := q[0].x - q[1].x + q[2].x - q[3].x
+	dy3 := q[0].y - q[1].y + q[2].y - q[3].y
+
+	if dx3 == 0 && dy3 == 0 {
+		return &perspective{
+			q[1].x - q[0].x, q[1].y - q[0].y, 0,
+			q[2].x - q[1].x, q[2].y - q[1].y, 0,
+			q[0].x, q[0].y, 1,
+		}
+	}
+
+	dx1, dx2 := q[1].x-q[2].x, q[3].x-q[2].x
+	dy1, dy2 := q[1].y-q[2].y, q[3].y-q[2].y
+	denominator := dx1*dy2 - dx2*dy1
+	a13 := (dx3*dy2 - dx2*dy3) / denominator
+	a23 := (dx1*dy3 - dx3*dy1) / denominator
+
+	return &perspective{
+		q[1].x - q[0].x + a13*q[1].x, q[1].y - q[0].y + a13*q[1].y, a13,
+		q[3].x - q[0].x + a23*q[3].x, q[3].y - q[0].y + a23*q[3].y, a23,
+		q[0].x, q[0].y, 1,
+	}
+}
+
+// adjoint returns the adjoint matrix, which inverts the transformation
+func (t *perspective) adjoint() *perspective {
+	return &perspective{
+		t.a22*t.a33 - t.a23*t.a32, t.a13*t.a32 - t.a12*t.a33, t.a12*t.a23 - t.a13*t.a22,
+		t.a23*t.a31 - t.a21*t.a33, t.a11*t.a33 - t.a13*t.a31, t.a13*t.a21 - t.a11*t.a23,
+		t.a21*t.a32 - t.a22*t.a31, t.a12*t.a31 - t.a11*t.a32, t.a11*t.a22 - t.a12*t.a21,
+	}
+}
+
+// times returns the transformation applying t after o
+func (t *perspective) times(o *perspective) *perspective {
+	return &perspective{
+		t.a11*o.a11 + t.a21*o.a12 + t.a31*o.a13,
+		t.a12*o.a11 + t.a22*o.a12 + t.a32*o.a13,
+		t.a13*o.a11 + t.a23*o.a12 + t.a33*o.a13,
+		t.a11*o.a21 + t.a21*o.a22 + t.a31*o.a23,
+		t.a12*o.a21 + t.a22*o.a22 + t.a32*o.a23,
+		t.a13*o.a21 + t.a23*o.a22 + t.a33*o.a23,
+		t.a11*o.a31 + t.a21*o.a32 + t.a31*o.a33,
+		t.a12*o.a31 + t.a22*o.a32 + t.a32*o.a33,
+		t.a13*o.a31 + t.a23*o.a32 + t.a33*o.a33,
+	}
+}
+
+// quadToQuad returns the transformation mapping quadrilateral from onto to
+func quadToQuad(from, to [4]point) *perspective {
+	return squareToQuad(to).times(squareToQuad(from).adjoint())
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
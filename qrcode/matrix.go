@@ -0,0 +1,339 @@
+package qrcode
+
+// matrix is a QR code symbol under construction or being read. Function
+// modules (finder, timing, alignment, format and version) are marked so data
+// placement and masking skip them.
+type matrix struct {
+	version  int
+	size     int
+	modules  []bool
+	function []bool
+}
+
+// newMatrix returns a matrix with all function patterns of version drawn.
+// Format information is reserved but not yet written.
+func newMatrix(version int) *matrix {
+	size := symbolSize(version)
+	m := &matrix{
+		version:  version,
+		size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+
+	// timing patterns
+	for i := 0; i < size; i++ {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+
+	// finder patterns with separators
+	m.drawFinder(3, 3)
+	m.drawFinder(size-4, 3)
+	m.drawFinder(3, size-4)
+
+	// alignment patterns, except where they would overlap finders
+	positions := alignmentPositions(version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			m.drawAlignment(x, y)
+		}
+	}
+
+	// reserve format information and draw the dark module
+	m.drawFormat(Low, 0)
+
+	m.drawVersion()
+
+	return m
+}
+
+func (m *matrix) get(x, y int) bool {
+	return m.modules[y*m.size+x]
+}
+
+func (m *matrix) set(x, y int, black bool) {
+	m.modules[y*m.size+x] = black
+}
+
+func (m *matrix) setFunction(x, y int, black bool) {
+	m.modules[y*m.size+x] = black
+	m.function[y*m.size+x] = true
+}
+
+func (m *matrix) isFunction(x, y int) bool {
+	return m.function[y*m.size+x]
+}
+
+// drawFinder draws a finder pattern and its separator centered at x, y
+func (m *matrix) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= m.size || yy >= m.size {
+				continue
+			}
+			d := maxInt(abs(dx), abs(dy))
+			m.setFunction(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawAlignment draws an alignment pattern centered at x, y
+func (m *matrix) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.setFunction(x+dx, y+dy, maxInt(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormat writes both copies of the format information
+func (m *matrix) drawFormat(level Level, mask int) {
+	bits := formatInformation(level, mask)
+	bit := func(i uint) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.setFunction(8, i, bit(uint(i)))
+	}
+	m.setFunction(8, 7, bit(6))
+	m.setFunction(8, 8, bit(7))
+	m.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(uint(i)))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.setFunction(m.size-1-i, 8, bit(uint(i)))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(uint(i)))
+	}
+
+	m.setFunction(8, m.size-8, true)
+}
+
+// drawVersion writes both copies of the version information (version 7+)
+func (m *matrix) drawVersion() {
+	if m.version < 7 {
+		return
+	}
+
+	bits := versionInformation(m.version)
+	for i := 0; i < 18; i++ {
+		black := bits>>uint(i)&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.setFunction(a, b, black)
+		m.setFunction(b, a, black)
+	}
+}
+
+// dataPositions calls fn for every data module in placement order
+func (m *matrix) dataPositions(fn func(x, y int)) {
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !m.isFunction(x, y) {
+					fn(x, y)
+				}
+			}
+		}
+	}
+}
+
+// placeData writes the codewords into the data modules
+func (m *matrix) placeData(codewords []byte) {
+	i := 0
+	m.dataPositions(func(x, y int) {
+		if i < len(codewords)*8 {
+			m.set(x, y, codewords[i/8]>>uint(7-i%8)&1 == 1)
+		}
+		i++
+	})
+}
+
+// readData returns the codewords stored in the data modules
+func (m *matrix) readData() []byte {
+	var bits bitBuffer
+	m.dataPositions(func(x, y int) {
+		bits.bits = append(bits.bits, m.get(x, y))
+	})
+	bits.bits = bits.bits[:len(bits.bits)/8*8]
+	return bits.bytes()
+}
+
+// maskFuncs are the eight data mask patterns
+var maskFuncs = [8]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+// applyMask flips the data modules selected by mask. Applying the same mask
+// twice restores the original data.
+func (m *matrix) applyMask(mask int) {
+	fn := maskFuncs[mask]
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if !m.isFunction(x, y) && fn(x, y) {
+				m.set(x, y, !m.get(x, y))
+			}
+		}
+	}
+}
+
+// penalty scores the symbol according to the four mask evaluation rules
+func (m *matrix) penalty() int {
+	penalty := 0
+
+	// rule 1 and 3 on rows and columns
+	for _, horizontal := range []bool{true, false} {
+		for a := 0; a < m.size; a++ {
+			line := make([]bool, m.size)
+			for b := 0; b < m.size; b++ {
+				if horizontal {
+					line[b] = m.get(b, a)
+				} else {
+					line[b] = m.get(a, b)
+				}
+			}
+			penalty += linePenalty(line)
+		}
+	}
+
+	// rule 2: 2x2 blocks of the same color
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			c := m.get(x, y)
+			if c {
+				dark++
+			}
+			if x < m.size-1 && y < m.size-1 && c == m.get(x+1, y) && c == m.get(x, y+1) && c == m.get(x+1, y+1) {
+				penalty += 3
+			}
+		}
+	}
+
+	// rule 4: balance of dark and light modules
+	total := m.size * m.size
+	penalty += abs(dark*100/total-50) / 5 * 10
+
+	return penalty
+}
+
+// linePenalty scores runs of the same color and finder like patterns
+func linePenalty(line []bool) int {
+	penalty := 0
+
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			penalty += 3 + run - 5
+		}
+		run = 1
+	}
+
+	pattern := []bool{true, false, true, true, true, false, true}
+	for i := 0; i+7 <= len(line); i++ {
+		match := true
+		for j, p := range pattern {
+			if line[i+j] != p {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		if lightRun(line, i-4, i) || lightRun(line, i+7, i+11) {
+			penalty += 40
+		}
+	}
+
+	return penalty
+}
+
+// lightRun reports whether line[from:to] is light, treating positions
+// outside of the symbol as light
+func lightRun(line []bool, from, to int) bool {
+	for i := from; i < to; i++ {
+		if i >= 0 && i < len(line) && line[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// alignmentPositions returns the row and column coordinates of the alignment
+// pattern centers of a version
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, pos := count-1, symbolSize(version)-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+
+	return positions
+}
+
+// formatInformation returns the masked 15 bit format information
+func formatInformation(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionInformation returns the 18 bit version information
+func versionInformation(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1f25
+	}
+	return version<<12 | rem
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,280 @@
+// Copyright 2015-2018 The gini-api-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package qrcode encodes and decodes QR codes in pure Go. It supports all 40
+versions and error correction levels. The encoder writes byte mode segments,
+which is what payment codes like GiroCode (EPC069-12) require. Decode finds
+all codes in an image, e.g. a scanned document page.
+*/
+package qrcode
+
+import (
+	"errors"
+)
+
+const (
+	ErrDataTooLong = "data too long for a QR code"
+	ErrInvalidCode = "invalid QR code"
+)
+
+// Level is the error correction level of a QR code
+type Level int
+
+// Error correction levels, recovering about 7%, 15%, 25% and 30% of data
+const (
+	Low Level = iota
+	Medium
+	Quartile
+	High
+)
+
+// formatBits returns the two bits used in the format information
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// String representation of a level
+func (l Level) String() string {
+	return [...]string{"L", "M", "Q", "H"}[l]
+}
+
+// blockInfo describes the error correction blocks of a version and level
+type blockInfo struct {
+	ecCodewords int
+	shortBlocks int
+	shortData   int
+	longBlocks  int
+	longData    int
+}
+
+// dataCodewords returns the number of data codewords
+func (b blockInfo) dataCodewords() int {
+	return b.shortBlocks*b.shortData + b.longBlocks*b.longData
+}
+
+// blocks returns the total number of blocks
+func (b blockInfo) blocks() int {
+	return b.shortBlocks + b.longBlocks
+}
+
+// blockTable holds the error correction layout per version and level:
+// codewords per block, short block count, short block data codewords,
+// long block count, long block data codewords
+var blockTable = [41][4]blockInfo{
+	{},
+	{{7, 1, 19, 0, 0}, {10, 1, 16, 0, 0}, {13, 1, 13, 0, 0}, {17, 1, 9, 0, 0}},
+	{{10, 1, 34, 0, 0}, {16, 1, 28, 0, 0}, {22, 1, 22, 0, 0}, {28, 1, 16, 0, 0}},
+	{{15, 1, 55, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 17, 0, 0}, {22, 2, 13, 0, 0}},
+	{{20, 1, 80, 0, 0}, {18, 2, 32, 0, 0}, {26, 2, 24, 0, 0}, {16, 4, 9, 0, 0}},
+	{{26, 1, 108, 0, 0}, {24, 2, 43, 0, 0}, {18, 2, 15, 2, 16}, {22, 2, 11, 2, 12}},
+	{{18, 2, 68, 0, 0}, {16, 4, 27, 0, 0}, {24, 4, 19, 0, 0}, {28, 4, 15, 0, 0}},
+	{{20, 2, 78, 0, 0}, {18, 4, 31, 0, 0}, {18, 2, 14, 4, 15}, {26, 4, 13, 1, 14}},
+	{{24, 2, 97, 0, 0}, {22, 2, 38, 2, 39}, {22, 4, 18, 2, 19}, {26, 4, 14, 2, 15}},
+	{{30, 2, 116, 0, 0}, {22, 3, 36, 2, 37}, {20, 4, 16, 4, 17}, {24, 4, 12, 4, 13}},
+	{{18, 2, 68, 2, 69}, {26, 4, 43, 1, 44}, {24, 6, 19, 2, 20}, {28, 6, 15, 2, 16}},
+	{{20, 4, 81, 0, 0}, {30, 1, 50, 4, 51}, {28, 4, 22, 4, 23}, {24, 3, 12, 8, 13}},
+	{{24, 2, 92, 2, 93}, {22, 6, 36, 2, 37}, {26, 4, 20, 6, 21}, {28, 7, 14, 4, 15}},
+	{{26, 4, 107, 0, 0}, {22, 8, 37, 1, 38}, {24, 8, 20, 4, 21}, {22, 12, 11, 4, 12}},
+	{{30, 3, 115, 1, 116}, {24, 4, 40, 5, 41}, {20, 11, 16, 5, 17}, {24, 11, 12, 5, 13}},
+	{{22, 5, 87, 1, 88}, {24, 5, 41, 5, 42}, {30, 5, 24, 7, 25}, {24, 11, 12, 7, 13}},
+	{{24, 5, 98, 1, 99}, {28, 7, 45, 3, 46}, {24, 15, 19, 2, 20}, {30, 3, 15, 13, 16}},
+	{{28, 1, 107, 5, 108}, {28, 10, 46, 1, 47}, {28, 1, 22, 15, 23}, {28, 2, 14, 17, 15}},
+	{{30, 5, 120, 1, 121}, {26, 9, 43, 4, 44}, {28, 17, 22, 1, 23}, {28, 2, 14, 19, 15}},
+	{{28, 3, 113, 4, 114}, {26, 3, 44, 11, 45}, {26, 17, 21, 4, 22}, {26, 9, 13, 16, 14}},
+	{{28, 3, 107, 5, 108}, {26, 3, 41, 13, 42}, {30, 15, 24, 5, 25}, {28, 15, 15, 10, 16}},
+	{{28, 4, 116, 4, 117}, {26, 17, 42, 0, 0}, {28, 17, 22, 6, 23}, {30, 19, 16, 6, 17}},
+	{{28, 2, 111, 7, 112}, {28, 17, 46, 0, 0}, {30, 7, 24, 16, 25}, {24, 34, 13, 0, 0}},
+	{{30, 4, 121, 5, 122}, {28, 4, 47, 14, 48}, {30, 11, 24, 14, 25}, {30, 16, 15, 14, 16}},
+	{{30, 6, 117, 4, 118}, {28, 6, 45, 14, 46}, {30, 11, 24, 16, 25}, {30, 30, 16, 2, 17}},
+	{{26, 8, 106, 4, 107}, {28, 8, 47, 13, 48}, {30, 7, 24, 22, 25}, {30, 22, 15, 13, 16}},
+	{{28, 10, 114, 2, 115}, {28, 19, 46, 4, 47}, {28, 28, 22, 6, 23}, {30, 33, 16, 4, 17}},
+	{{30, 8, 122, 4, 123}, {28, 22, 45, 3, 46}, {30, 8, 23, 26, 24}, {30, 12, 15, 28, 16}},
+	{{30, 3, 117, 10, 118}, {28, 3, 45, 23, 46}, {30, 4, 24, 31, 25}, {30, 11, 15, 31, 16}},
+	{{30, 7, 116, 7, 117}, {28, 21, 45, 7, 46}, {30, 1, 23, 37, 24}, {30, 19, 15, 26, 16}},
+	{{30, 5, 115, 10, 116}, {28, 19, 47, 10, 48}, {30, 15, 24, 25, 25}, {30, 23, 15, 25, 16}},
+	{{30, 13, 115, 3, 116}, {28, 2, 46, 29, 47}, {30, 42, 24, 1, 25}, {30, 23, 15, 28, 16}},
+	{{30, 17, 115, 0, 0}, {28, 10, 46, 23, 47}, {30, 10, 24, 35, 25}, {30, 19, 15, 35, 16}},
+	{{30, 17, 115, 1, 116}, {28, 14, 46, 21, 47}, {30, 29, 24, 19, 25}, {30, 11, 15, 46, 16}},
+	{{30, 13, 115, 6, 116}, {28, 14, 46, 23, 47}, {30, 44, 24, 7, 25}, {30, 59, 16, 1, 17}},
+	{{30, 12, 121, 7, 122}, {28, 12, 47, 26, 48}, {30, 39, 24, 14, 25}, {30, 22, 15, 41, 16}},
+	{{30, 6, 121, 14, 122}, {28, 6, 47, 34, 48}, {30, 46, 24, 10, 25}, {30, 2, 15, 64, 16}},
+	{{30, 17, 122, 4, 123}, {28, 29, 46, 14, 47}, {30, 49, 24, 10, 25}, {30, 24, 15, 46, 16}},
+	{{30, 4, 122, 18, 123}, {28, 13, 46, 32, 47}, {30, 48, 24, 14, 25}, {30, 42, 15, 32, 16}},
+	{{30, 20, 117, 4, 118}, {28, 40, 47, 7, 48}, {30, 43, 24, 22, 25}, {30, 10, 15, 67, 16}},
+	{{30, 19, 118, 6, 119}, {28, 18, 47, 31, 48}, {30, 34, 24, 34, 25}, {30, 20, 15, 61, 16}},
+}
+
+// Code is a QR code symbol
+type Code struct {
+	Version int
+	Level   Level
+	Mask    int
+	// Size is the number of modules per side
+	Size    int
+	modules []bool
+}
+
+// Black reports whether the module at column x and row y is dark. Modules
+// outside of the symbol are light.
+func (c *Code) Black(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y*c.Size+x]
+}
+
+// symbolSize returns the modules per side of a version
+func symbolSize(version int) int {
+	return version*4 + 17
+}
+
+// countBits returns the length of the byte mode character count indicator
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// Encode returns the smallest QR code holding data in a byte mode segment
+// with at least the given error correction level
+func Encode(data []byte, level Level) (*Code, error) {
+	return EncodeVersion(data, level, 1, 40)
+}
+
+// EncodeVersion is like Encode but restricts the version to the given range,
+// e.g. EPC069-12 allows at most version 13
+func EncodeVersion(data []byte, level Level, minVersion, maxVersion int) (*Code, error) {
+	if minVersion < 1 {
+		minVersion = 1
+	}
+	if maxVersion > 40 {
+		maxVersion = 40
+	}
+
+	for version := minVersion; version <= maxVersion; version++ {
+		capacity := blockTable[version][level].dataCodewords() * 8
+		if 4+countBits(version)+len(data)*8 <= capacity {
+			return encode(data, level, version), nil
+		}
+	}
+
+	return nil, errors.New(ErrDataTooLong)
+}
+
+// encode builds the symbol for data, which is known to fit into version
+func encode(data []byte, level Level, version int) *Code {
+	info := blockTable[version][level]
+
+	var bits bitBuffer
+	bits.append(4, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacity := info.dataCodewords() * 8
+	terminator := capacity - bits.len()
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-bits.len()%8)%8)
+
+	codewords := bits.bytes()
+	for pad := byte(0xec); len(codewords) < info.dataCodewords(); pad ^= 0xec ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+
+	m := newMatrix(version)
+	m.placeData(interleave(codewords, info))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormat(level, mask)
+		if penalty := m.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		m.applyMask(mask)
+	}
+
+	m.applyMask(best)
+	m.drawFormat(level, best)
+
+	return &Code{
+		Version: version,
+		Level:   level,
+		Mask:    best,
+		Size:    m.size,
+		modules: m.modules,
+	}
+}
+
+// interleave splits data codewords into blocks, adds error correction and
+// returns the codewords in transmission order
+func interleave(data []byte, info blockInfo) []byte {
+	blocks := make([][]byte, info.blocks())
+	ec := make([][]byte, info.blocks())
+
+	offset := 0
+	for i := range blocks {
+		n := info.shortData
+		if i >= info.shortBlocks {
+			n = info.longData
+		}
+		blocks[i] = data[offset : offset+n]
+		ec[i] = rsEncode(blocks[i], info.ecCodewords)
+		offset += n
+	}
+
+	var result []byte
+
+	maxData := info.shortData
+	if info.longBlocks > 0 {
+		maxData = info.longData
+	}
+
+	for i := 0; i < maxData; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	for i := 0; i < info.ecCodewords; i++ {
+		for _, block := range ec {
+			result = append(result, block[i])
+		}
+	}
+
+	return result
+}
+
+// bitBuffer collects bits most significant first
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		b.bits = append(b.bits, value>>uint(i)&1 == 1)
+	}
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+func (b *bitBuffer) bytes() []byte {
+	result := make([]byte, (len(b.bits)+7)/8)
+	for i, bit := range b.bits {
+		if bit {
+			result[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return result
+}
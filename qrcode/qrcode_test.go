@@ -0,0 +1,112 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func Test_blockTable(t *testing.T) {
+	for version := 1; version <= 40; version++ {
+		// raw data modules minus function patterns, see ISO 18004 table 1
+		modules := (16*version+128)*version + 64
+		if version >= 2 {
+			count := version/7 + 2
+			modules -= (25*count-10)*count - 55
+			if version >= 7 {
+				modules -= 36
+			}
+		}
+
+		for level := Low; level <= High; level++ {
+			info := blockTable[version][level]
+			total := info.dataCodewords() + info.blocks()*info.ecCodewords
+			if total != modules/8 {
+				t.Errorf("version %d-%s: %d codewords, expected %d", version, level, total, modules/8)
+			}
+		}
+	}
+}
+
+func Test_alignmentPositions(t *testing.T) {
+	expected := map[int]string{
+		2:  "[6 18]",
+		7:  "[6 22 38]",
+		13: "[6 34 62]",
+		32: "[6 34 60 86 112 138]",
+		40: "[6 30 58 86 114 142 170]",
+	}
+
+	for version, positions := range expected {
+		if got := fmt.Sprint(alignmentPositions(version)); got != positions {
+			t.Errorf("version %d: %s != %s", version, got, positions)
+		}
+	}
+}
+
+func Test_formatInformation(t *testing.T) {
+	// values from ISO 18004 annex C
+	assertEqual(t, formatInformation(Medium, 0), 0x5412, "")
+	assertEqual(t, formatInformation(Low, 4), 0x662f, "")
+	assertEqual(t, versionInformation(7), 0x07c94, "")
+}
+
+func Test_Encode(t *testing.T) {
+	code, err := Encode([]byte("HELLO WORLD"), Medium)
+	assertEqual(t, err, nil, "")
+	assertEqual(t, code.Version, 1, "")
+	assertEqual(t, code.Size, 21, "")
+
+	// finder pattern corners and dark module
+	assertEqual(t, code.Black(0, 0), true, "")
+	assertEqual(t, code.Black(7, 7), false, "")
+	assertEqual(t, code.Black(20, 0), true, "")
+	assertEqual(t, code.Black(0, 20), true, "")
+	assertEqual(t, code.Black(8, 13), true, "")
+	assertEqual(t, code.Black(-1, 0), false, "")
+
+	// 100 data codewords in version 9-H leave no room for mode and length
+	code, err = EncodeVersion(bytes.Repeat([]byte("a"), 100), High, 1, 40)
+	assertEqual(t, err, nil, "")
+	assertEqual(t, code.Version, 10, "")
+
+	_, err = EncodeVersion(bytes.Repeat([]byte("a"), 332), Medium, 1, 13)
+	assertNotEqual(t, err, nil, "")
+}
+
+func Test_CodeWriteImages(t *testing.T) {
+	code, _ := Encode([]byte("gini"), Low)
+
+	var buf bytes.Buffer
+	assertEqual(t, code.WritePNG(&buf, 4), nil, "")
+
+	img, err := png.Decode(&buf)
+	assertEqual(t, err, nil, "")
+	assertEqual(t, img.Bounds().Dx(), (21+2*QuietZone)*4, "")
+
+	buf.Reset()
+	assertEqual(t, code.WriteSVG(&buf, 200), nil, "")
+	assertEqual(t, strings.Contains(buf.String(), `viewBox="0 0 29 29"`), true, "")
+	assertEqual(t, strings.Contains(buf.String(), "M4 4h7v1h-7z"), true, "")
+}
+
+func assertEqual(t *testing.T, a interface{}, b interface{}, message string) {
+	t.Helper()
+	if a == b {
+		return
+	}
+	if len(message) == 0 {
+		message = "values differ"
+	}
+	t.Fatalf("%s: %v != %v", message, a, b)
+}
+
+func assertNotEqual(t *testing.T, a interface{}, b interface{}, message string) {
+	t.Helper()
+	if a != b {
+		return
+	}
+	t.Fatalf("%s: %v == %v", message, a, b)
+}
@@ -0,0 +1,193 @@
+package qrcode
+
+import (
+	"errors"
+)
+
+// Arithmetic in GF(256) with the QR code polynomial x^8 + x^4 + x^3 + x^2 + 1
+var (
+	gfExp [512]byte
+	gfLog [256]int
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// rsGenerator returns the generator polynomial of the given degree, highest
+// coefficient first
+func rsGenerator(degree int) []byte {
+	g := []byte{1}
+	for i := 0; i < degree; i++ {
+		next := make([]byte, len(g)+1)
+		for j, c := range g {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfExp[i])
+		}
+		g = next
+	}
+	return g
+}
+
+// rsEncode returns the n error correction codewords for data
+func rsEncode(data []byte, n int) []byte {
+	g := rsGenerator(n)
+	rem := make([]byte, n)
+
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for i := 0; i < n; i++ {
+			rem[i] ^= gfMul(g[i+1], factor)
+		}
+	}
+
+	return rem
+}
+
+// rsCorrect corrects up to n/2 errors in place in block, which holds data
+// followed by n error correction codewords. It returns the number of
+// corrected codewords.
+func rsCorrect(block []byte, n int) (int, error) {
+	length := len(block)
+
+	// syndromes S_i = r(a^i), lowest degree first
+	syndromes := make([]byte, n)
+	clean := true
+	for i := 0; i < n; i++ {
+		var s byte
+		for _, c := range block {
+			s = gfMul(s, gfExp[i]) ^ c
+		}
+		syndromes[i] = s
+		if s != 0 {
+			clean = false
+		}
+	}
+	if clean {
+		return 0, nil
+	}
+
+	// Berlekamp-Massey to find the error locator polynomial
+	locator := []byte{1}
+	prev := []byte{1}
+	errorCount, shift, lastDiscrepancy := 0, 1, byte(1)
+
+	for k := 0; k < n; k++ {
+		discrepancy := syndromes[k]
+		for i := 1; i <= errorCount && i < len(locator); i++ {
+			discrepancy ^= gfMul(locator[i], syndromes[k-i])
+		}
+
+		if discrepancy == 0 {
+			shift++
+			continue
+		}
+
+		factor := gfDiv(discrepancy, lastDiscrepancy)
+		next := make([]byte, maxInt(len(locator), len(prev)+shift))
+		copy(next, locator)
+		for i, c := range prev {
+			next[i+shift] ^= gfMul(factor, c)
+		}
+
+		if 2*errorCount <= k {
+			prev = locator
+			errorCount = k + 1 - errorCount
+			lastDiscrepancy = discrepancy
+			shift = 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+
+	locator = trimPoly(locator)
+	if len(locator)-1 != errorCount || 2*errorCount > n {
+		return 0, errors.New(ErrInvalidCode)
+	}
+
+	// Chien search: codeword i has the power length-1-i
+	var positions []int
+	for power := 0; power < length; power++ {
+		if polyEval(locator, gfExp[(255-power)%255]) == 0 {
+			positions = append(positions, power)
+		}
+	}
+	if len(positions) != errorCount {
+		return 0, errors.New(ErrInvalidCode)
+	}
+
+	// evaluator polynomial: S(x) * locator(x) mod x^n
+	evaluator := make([]byte, n)
+	for i, s := range syndromes {
+		for j, l := range locator {
+			if i+j < n {
+				evaluator[i+j] ^= gfMul(s, l)
+			}
+		}
+	}
+
+	// formal derivative of the locator
+	derivative := make([]byte, len(locator))
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+
+	// Forney algorithm for the error magnitudes
+	for _, power := range positions {
+		x := gfExp[power]
+		xInverse := gfExp[(255-power)%255]
+		denominator := polyEval(derivative, xInverse)
+		if denominator == 0 {
+			return 0, errors.New(ErrInvalidCode)
+		}
+		magnitude := gfMul(x, gfDiv(polyEval(evaluator, xInverse), denominator))
+		block[length-1-power] ^= magnitude
+	}
+
+	return errorCount, nil
+}
+
+// polyEval evaluates a polynomial with lowest degree coefficient first
+func polyEval(poly []byte, x byte) byte {
+	var result byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		result = gfMul(result, x) ^ poly[i]
+	}
+	return result
+}
+
+// trimPoly removes zero coefficients of the highest degrees
+func trimPoly(poly []byte) []byte {
+	for len(poly) > 1 && poly[len(poly)-1] == 0 {
+		poly = poly[:len(poly)-1]
+	}
+	return poly
+}
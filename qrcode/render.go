@@ -0,0 +1,77 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// QuietZone is the recommended light border around a symbol in modules
+const QuietZone = 4
+
+// Image renders the code with scale pixels per module and a quiet zone
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Black(x, y) {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				offset := img.PixOffset((x+QuietZone)*scale, (y+QuietZone)*scale+dy)
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[offset+dx] = 1
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// WritePNG writes the code as PNG image with scale pixels per module
+func (c *Code) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, c.Image(scale))
+}
+
+// WriteSVG writes the code as SVG image with a width and height of size
+// user units. Dark modules are combined into a single path.
+func (c *Code) WriteSVG(w io.Writer, size int) error {
+	side := c.Size + 2*QuietZone
+
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">
+<rect width="100%%" height="100%%" fill="#ffffff"/>
+<path fill="#000000" d="`, size, size, side, side)
+	if err != nil {
+		return err
+	}
+
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Black(x, y) {
+				continue
+			}
+			// merge horizontal runs of dark modules
+			run := 1
+			for c.Black(x+run, y) {
+				run++
+			}
+			if _, err := fmt.Fprintf(w, "M%d %dh%dv1h-%dz", x+QuietZone, y+QuietZone, run, run); err != nil {
+				return err
+			}
+			x += run - 1
+		}
+	}
+
+	_, err = io.WriteString(w, "\"/>\n</svg>\n")
+	return err
+}
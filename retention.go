@@ -0,0 +1,147 @@
+package giniapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RetentionPolicy selects documents to delete with EnforceRetention
+type RetentionPolicy struct {
+	// MaxAge selects documents created more than MaxAge ago, 0 selects
+	// documents of any age. Documents without creation date are skipped.
+	MaxAge time.Duration
+	// Select is an additional predicate, documents are only deleted if it
+	// returns true (nil selects all)
+	Select func(d *Document) bool
+	// Workers is the number of concurrent deletions (default 4)
+	Workers int
+	// Rate limits the deletions per second, 0 or rates above one per
+	// nanosecond do not limit
+	Rate float64
+	// DryRun selects the documents without deleting them
+	DryRun bool
+}
+
+// RetentionFailure is a document which could not be deleted
+type RetentionFailure struct {
+	Document *Document
+	Response APIResponse
+}
+
+// RetentionReport lists the documents selected and deleted by
+// EnforceRetention
+type RetentionReport struct {
+	DryRun bool
+	// Selected documents in listing order
+	Selected []*Document
+	// Deleted documents in listing order, empty for dry runs
+	Deleted []*Document
+	// Failed deletions, including documents skipped after the context
+	// was canceled
+	Failed []RetentionFailure
+}
+
+// EnforceRetention deletes all documents of a listing selected by the
+// policy. The filters of the options (e.g. UserIdentifier, Progress, Name)
+// restrict the documents, MaxAge and Select of the policy are applied on top.
+// All documents are selected before the first deletion. The report is
+// returned in any case, the response has an error if the listing or any
+// deletion failed.
+func (api *APIClient) EnforceRetention(ctx context.Context, options ListOptions, policy RetentionPolicy) (*RetentionReport, APIResponse) {
+	report := &RetentionReport{DryRun: policy.DryRun}
+
+	if policy.MaxAge > 0 {
+		cutoff := time.Now().Add(-policy.MaxAge)
+		if options.CreatedBefore.IsZero() || cutoff.Before(options.CreatedBefore) {
+			options.CreatedBefore = cutoff
+		}
+	}
+	options.SortBy = ""
+
+	it := api.Iterate(ctx, options)
+	for it.Next() {
+		if doc := it.Document(); policy.Select == nil || policy.Select(doc) {
+			report.Selected = append(report.Selected, doc)
+		}
+	}
+	if resp := it.Response(); resp.Error != nil {
+		return report, resp
+	}
+
+	if policy.DryRun || len(report.Selected) == 0 {
+		return report, apiResponse(fmt.Sprintf("retention selected %d documents", len(report.Selected)), "", nil, nil)
+	}
+
+	responses := api.deleteAll(ctx, report.Selected, policy)
+
+	for i, resp := range responses {
+		if resp.Error != nil {
+			report.Failed = append(report.Failed, RetentionFailure{Document: report.Selected[i], Response: resp})
+		} else {
+			report.Deleted = append(report.Deleted, report.Selected[i])
+		}
+	}
+
+	if len(report.Failed) > 0 {
+		err := fmt.Errorf("%s: %d of %d deletions failed", ErrRetentionFailed, len(report.Failed), len(report.Selected))
+		return report, apiResponse(ErrRetentionFailed, "", nil, err)
+	}
+
+	return report, apiResponse(fmt.Sprintf("retention deleted %d documents", len(report.Deleted)), "", nil, nil)
+}
+
+// deleteAll deletes the documents concurrently and returns the response of
+// every deletion
+func (api *APIClient) deleteAll(ctx context.Context, docs []*Document, policy RetentionPolicy) []APIResponse {
+	workers := policy.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	var tick <-chan time.Time
+	if interval := time.Duration(float64(time.Second) / policy.Rate); policy.Rate > 0 && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	responses := make([]APIResponse, len(docs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				responses[i] = docs[i].Delete(ctx)
+			}
+		}()
+	}
+
+	for i := range docs {
+		if tick != nil && i > 0 {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			responses[i] = apiResponse("retention aborted", docs[i].ID, nil, err)
+			continue
+		}
+
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			responses[i] = apiResponse("retention aborted", docs[i].ID, nil, ctx.Err())
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return responses
+}
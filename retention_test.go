@@ -0,0 +1,98 @@
+package giniapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_EnforceRetention(t *testing.T) {
+	client, listing, stop := testListingClient(t, 7, nil)
+	defer stop()
+
+	// documents 6 and 7 are recent
+	listing.created = map[string]time.Time{"6": time.Now(), "7": time.Now()}
+
+	policy := RetentionPolicy{
+		MaxAge: 24 * time.Hour,
+		Select: func(d *Document) bool { return d.ID != "1" },
+		DryRun: true,
+	}
+	options := ListOptions{Limit: 2, UserIdentifier: "user1", Name: "*.jpg"}
+
+	report, resp := client.EnforceRetention(context.Background(), options, policy)
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, report.DryRun, true, "")
+	assertEqual(t, testIDs(report.Selected), "[5 3]", "")
+	assertEqual(t, len(report.Deleted), 0, "")
+	assertEqual(t, len(listing.deleted), 0, "")
+
+	policy.DryRun = false
+	report, resp = client.EnforceRetention(context.Background(), options, policy)
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, resp.Message, "retention deleted 2 documents", "")
+	assertEqual(t, testIDs(report.Deleted), "[5 3]", "")
+
+	listing.Lock()
+	sort.Strings(listing.deleted)
+	assertEqual(t, fmt.Sprint(listing.deleted), "[3 5]", "")
+	assertEqual(t, fmt.Sprint(listing.ids), "[7 6 4 2 1]", "")
+	listing.Unlock()
+
+	// nothing left to delete
+	report, resp = client.EnforceRetention(context.Background(), options, policy)
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, len(report.Selected), 0, "")
+}
+
+func Test_EnforceRetentionFailures(t *testing.T) {
+	client, listing, stop := testListingClient(t, 6, nil)
+	defer stop()
+
+	listing.fail = map[string]bool{"4": true}
+
+	start := time.Now()
+	report, resp := client.EnforceRetention(context.Background(), ListOptions{UserIdentifier: "user1"}, RetentionPolicy{Workers: 2, Rate: 50})
+
+	// 6 deletions at 50 per second take at least 5 intervals of 20ms
+	assertEqual(t, time.Since(start) >= 100*time.Millisecond, true, "")
+
+	assertEqual(t, resp.Message, ErrRetentionFailed, "")
+	assertEqual(t, strings.Contains(resp.Error.Error(), "1 of 6"), true, "")
+	assertEqual(t, testIDs(report.Deleted), "[6 5 3 2 1]", "")
+	assertEqual(t, len(report.Failed), 1, "")
+	assertEqual(t, report.Failed[0].Document.ID, "4", "")
+	assertEqual(t, report.Failed[0].Response.Message, ErrDocumentDelete, "")
+
+	// canceled contexts skip the remaining deletions
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	docs := []*Document{{ID: "a"}, {ID: "b"}}
+	responses := client.deleteAll(ctx, docs, RetentionPolicy{})
+	assertEqual(t, responses[0].Error, context.Canceled, "")
+	assertEqual(t, responses[1].Error, context.Canceled, "")
+}
+
+func Test_EnforceRetentionUnknownAge(t *testing.T) {
+	client, listing, stop := testListingClient(t, 3, nil)
+	defer stop()
+
+	// document 2 has no creation date
+	listing.created = map[string]time.Time{"2": {}}
+
+	report, resp := client.EnforceRetention(context.Background(), ListOptions{UserIdentifier: "user1"}, RetentionPolicy{MaxAge: time.Hour, Rate: 1e12})
+
+	assertEqual(t, resp.Error, nil, "")
+	assertEqual(t, testIDs(report.Deleted), "[3 1]", "")
+
+	listing.Lock()
+	assertEqual(t, fmt.Sprint(listing.ids), "[2]", "")
+	listing.Unlock()
+}
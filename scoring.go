@@ -0,0 +1,209 @@
+package giniapi
+
+import (
+	"sort"
+)
+
+// Score factors applied to a label. A perfect label scores 1.
+const (
+	scoreNoCandidates = 0.7 // extraction without candidates to compare with
+	scoreOffText      = 0.6 // box does not overlap any word of the layout
+	scoreOffRegion    = 0.8 // box lies outside of the expected region
+)
+
+// ScoreOptions specify parameters to the Score function
+type ScoreOptions struct {
+	// Labels to score. Defaults to all extracted labels.
+	Labels []string
+	// Layout of the document. If set, boxes are checked against words and
+	// regions of the page layout.
+	Layout *Layout
+	// Regions maps labels to the region type they are expected in,
+	// e.g. "iban": "RemittanceSlip". Requires Layout.
+	Regions map[string]string
+	// Rules to validate the extractions with. A label with violations scores 0.
+	Rules []Rule
+}
+
+// LabelScore holds the review score of a single label and its inputs
+type LabelScore struct {
+	Label string
+	// Score between 0 (needs review) and 1 (trustworthy)
+	Score float64
+	// Candidates is the number of candidates for the label
+	Candidates int
+	// Agreement is the share of candidates agreeing with the extracted value
+	Agreement float64
+	// OnText reports whether the box overlaps words of the layout
+	OnText bool
+	// InRegion reports whether the box lies in the expected region
+	InRegion bool
+	// Violations of the validation rules for this label
+	Violations []Violation
+}
+
+// ReviewScore is the review score of a document
+type ReviewScore struct {
+	// Score is the lowest label score
+	Score float64
+	// Mean is the average label score
+	Mean float64
+	// Labels holds the individual label scores
+	Labels map[string]LabelScore
+}
+
+// NeedsReview reports whether any label scores below threshold
+func (s ReviewScore) NeedsReview(threshold float64) bool {
+	return s.Score < threshold
+}
+
+// Weakest returns the label scores ordered from lowest to highest score
+func (s ReviewScore) Weakest() []LabelScore {
+	scores := make([]LabelScore, 0, len(s.Labels))
+	for _, score := range s.Labels {
+		scores = append(scores, score)
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score == scores[j].Score {
+			return scores[i].Label < scores[j].Label
+		}
+		return scores[i].Score < scores[j].Score
+	})
+
+	return scores
+}
+
+// Score rates how trustworthy the extractions are. Every label starts from
+// the agreement of its candidates with the extracted value, is lowered if its
+// box is not backed by layout words or lies outside of its expected region
+// and drops to 0 on validation errors or if the label is missing.
+func (e *Extractions) Score(options ScoreOptions) ReviewScore {
+	labels := options.Labels
+	if len(labels) == 0 {
+		for label := range e.Extractions {
+			labels = append(labels, label)
+		}
+	}
+
+	violations := e.Validate(options.Rules...)
+
+	review := ReviewScore{
+		Score:  1,
+		Labels: make(map[string]LabelScore, len(labels)),
+	}
+
+	if len(labels) == 0 {
+		return review
+	}
+
+	var total float64
+
+	for _, label := range labels {
+		score := e.scoreLabel(label, options)
+		score.Violations = violations[label]
+
+		if len(score.Violations) > 0 {
+			score.Score = 0
+		}
+
+		review.Labels[label] = score
+		total += score.Score
+
+		if score.Score < review.Score {
+			review.Score = score.Score
+		}
+	}
+
+	review.Mean = total / float64(len(labels))
+
+	return review
+}
+
+// scoreLabel computes the score of a label without validation results
+func (e *Extractions) scoreLabel(label string, options ScoreOptions) LabelScore {
+	score := LabelScore{Label: label, OnText: true, InRegion: true}
+
+	extraction, ok := e.Extractions[label]
+	if !ok || extraction.Value == "" {
+		score.OnText, score.InRegion = false, false
+		return score
+	}
+
+	candidates := e.Candidates[extraction.Candidates]
+	score.Candidates = len(candidates)
+	score.Score = scoreNoCandidates
+
+	if len(candidates) > 0 {
+		agreeing := 0
+		for _, candidate := range candidates {
+			if candidate.Value == extraction.Value {
+				agreeing++
+			}
+		}
+		score.Agreement = float64(agreeing) / float64(len(candidates))
+		score.Score = 0.5 + 0.5*score.Agreement
+	}
+
+	if options.Layout == nil {
+		return score
+	}
+
+	page := options.Layout.Page(extraction.Box.Page)
+	if page == nil {
+		score.OnText = false
+		score.Score *= scoreOffText
+		return score
+	}
+
+	box := extraction.Box.Coordinates()
+
+	score.OnText = page.overlapsWords(box)
+	if !score.OnText {
+		score.Score *= scoreOffText
+	}
+
+	if regionType, ok := options.Regions[label]; ok {
+		score.InRegion = page.inRegion(box, regionType)
+		if !score.InRegion {
+			score.Score *= scoreOffRegion
+		}
+	}
+
+	return score
+}
+
+// overlapsWords reports whether any word of the page intersects box
+func (p *PageLayout) overlapsWords(box PageCoordinates) bool {
+	for _, zone := range p.TextZones {
+		for _, paragraph := range zone.Paragraphs {
+			if !paragraph.Intersects(box) {
+				continue
+			}
+			for _, line := range paragraph.Lines {
+				for _, word := range line.Words {
+					if word.Intersects(box) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// inRegion reports whether box lies inside a region of the given type. Pages
+// without such a region never count against the box.
+func (p *PageLayout) inRegion(box PageCoordinates, regionType string) bool {
+	found := false
+	for _, region := range p.Regions {
+		if region.Type != regionType {
+			continue
+		}
+		if region.Contains(box) {
+			return true
+		}
+		found = true
+	}
+	return !found
+}
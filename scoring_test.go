@@ -0,0 +1,78 @@
+package giniapi
+
+import (
+	"context"
+	"testing"
+)
+
+func testLayout(t *testing.T) *Layout {
+	doc := Document{
+		client: testOauthClient(t),
+		Links: Links{
+			Layout: testHTTPServer.URL + "/test/layout",
+		},
+	}
+
+	layout, resp := doc.GetLayout(context.Background())
+	if resp.Error != nil {
+		t.Fatalf("Failed to get layout: %s", resp.Error)
+	}
+	return layout
+}
+
+func Test_ExtractionsScore(t *testing.T) {
+	extractions := &Extractions{
+		Extractions: map[string]Extraction{
+			"amountToPay": {
+				Box:        Box{Page: 1, Left: 54, Top: 158, Width: 20, Height: 10},
+				Entity:     "amount",
+				Value:      "24.99:EUR",
+				Candidates: "amounts",
+			},
+			"iban": {
+				Box:    Box{Page: 1, Left: 300, Top: 500, Width: 80, Height: 10},
+				Entity: "iban",
+				Value:  "DE89370400440532013000",
+			},
+		},
+		Candidates: map[string][]Extraction{
+			"amounts": {
+				{Entity: "amount", Value: "24.99:EUR"},
+				{Entity: "amount", Value: "21.00:EUR"},
+			},
+		},
+	}
+
+	// candidates only
+	review := extractions.Score(ScoreOptions{})
+	assertEqual(t, review.Labels["amountToPay"].Candidates, 2, "")
+	assertEqual(t, review.Labels["amountToPay"].Agreement, 0.5, "")
+	assertEqual(t, review.Labels["amountToPay"].Score, 0.75, "")
+	assertEqual(t, review.Labels["iban"].Score, scoreNoCandidates, "")
+	assertEqual(t, review.Score, scoreNoCandidates, "")
+	assertEqual(t, review.NeedsReview(0.9), true, "")
+
+	// layout and regions
+	review = extractions.Score(ScoreOptions{
+		Layout:  testLayout(t),
+		Regions: map[string]string{"iban": "RemittanceSlip"},
+	})
+	assertEqual(t, review.Labels["amountToPay"].OnText, true, "")
+	assertEqual(t, review.Labels["iban"].OnText, false, "")
+	assertEqual(t, review.Labels["iban"].InRegion, false, "")
+	assertEqual(t, review.Weakest()[0].Label, "iban", "")
+
+	// missing and invalid labels
+	review = extractions.Score(ScoreOptions{
+		Labels: []string{"amountToPay", "paymentRecipient"},
+		Rules:  []Rule{PositiveAmount("amountToPay")},
+	})
+	assertEqual(t, review.Labels["paymentRecipient"].Score, 0.0, "")
+	assertEqual(t, review.Score, 0.0, "")
+	assertEqual(t, review.Mean, 0.375, "")
+
+	extractions.Extractions["amountToPay"] = Extraction{Entity: "amount", Value: "0:EUR"}
+	review = extractions.Score(ScoreOptions{Rules: []Rule{PositiveAmount("amountToPay")}})
+	assertEqual(t, len(review.Labels["amountToPay"].Violations), 1, "")
+	assertEqual(t, review.Labels["amountToPay"].Score, 0.0, "")
+}
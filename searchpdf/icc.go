@@ -0,0 +1,108 @@
+package searchpdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// srgbProfile builds a compact ICC v2 display profile with the sRGB
+// primaries (adapted to D50) and a gamma 2.2 tone curve. It is embedded as
+// output intent, which PDF/A requires for device dependent colors.
+func srgbProfile() []byte {
+	type tag struct {
+		signature string
+		data      []byte
+	}
+
+	xyz := func(x, y, z float64) []byte {
+		var b bytes.Buffer
+		b.WriteString("XYZ ")
+		b.Write(make([]byte, 4))
+		for _, v := range []float64{x, y, z} {
+			binary.Write(&b, binary.BigEndian, int32(math.Round(v*65536)))
+		}
+		return b.Bytes()
+	}
+
+	text := func(s string) []byte {
+		var b bytes.Buffer
+		b.WriteString("text")
+		b.Write(make([]byte, 4))
+		b.WriteString(s)
+		b.WriteByte(0)
+		return b.Bytes()
+	}
+
+	description := func(s string) []byte {
+		var b bytes.Buffer
+		b.WriteString("desc")
+		b.Write(make([]byte, 4))
+		binary.Write(&b, binary.BigEndian, uint32(len(s)+1))
+		b.WriteString(s)
+		b.WriteByte(0)
+		// empty unicode and script code descriptions
+		b.Write(make([]byte, 4+4+2+1+67))
+		return b.Bytes()
+	}
+
+	// gamma 2.2 as u8Fixed8Number
+	curve := []byte{'c', 'u', 'r', 'v', 0, 0, 0, 0, 0, 0, 0, 1, 0x02, 0x33}
+
+	tags := []tag{
+		{"desc", description(outputCondition)},
+		{"cprt", text("No copyright, use freely")},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	pad := func(n int) int { return (n + 3) &^ 3 }
+
+	// tag data follows the header and the tag table, identical data is
+	// shared between tags
+	offset := 128 + 4 + 12*len(tags)
+	offsets := make([]int, len(tags))
+	var data bytes.Buffer
+	for i, t := range tags {
+		if i > 0 && bytes.Equal(t.data, tags[i-1].data) {
+			offsets[i] = offsets[i-1]
+			continue
+		}
+		offsets[i] = offset + data.Len()
+		data.Write(t.data)
+		data.Write(make([]byte, pad(len(t.data))-len(t.data)))
+	}
+
+	size := offset + data.Len()
+
+	var b bytes.Buffer
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(size))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	for i, v := range []uint16{2018, 1, 1, 0, 0, 0} {
+		binary.BigEndian.PutUint16(header[24+2*i:], v)
+	}
+	copy(header[36:], "acsp")
+	// D50 illuminant of the profile connection space
+	copy(header[68:], xyz(0.9642, 1.0, 0.8249)[8:])
+	b.Write(header)
+
+	binary.Write(&b, binary.BigEndian, uint32(len(tags)))
+	for i, t := range tags {
+		b.WriteString(t.signature)
+		binary.Write(&b, binary.BigEndian, uint32(offsets[i]))
+		binary.Write(&b, binary.BigEndian, uint32(len(t.data)))
+	}
+
+	b.Write(data.Bytes())
+
+	return b.Bytes()
+}
@@ -0,0 +1,127 @@
+// Copyright 2015-2018 The gini-api-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package searchpdf creates searchable PDFs from documents processed by Gini.
+Every page shows the processed (rectified) page image, the words of the
+layout are placed over it as invisible text. Viewers can select, copy and
+search the text and archives can index it without running OCR again.
+
+	f, _ := os.Create("invoice.pdf")
+	defer f.Close()
+
+	resp := searchpdf.FromDocument(ctx, doc, nil, "", f, searchpdf.Options{Title: doc.Name})
+
+The files follow PDF/A-2b: images are sRGB or gray with an embedded sRGB
+output intent, XMP metadata matches the document information and the text is
+set in rendering mode 3 (invisible) with a standard font, which PDF/A does
+not require to be embedded.
+*/
+package searchpdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/dkerwin/gini-api-go"
+)
+
+// ErrNoPages is returned when a PDF without pages is requested
+const ErrNoPages = "no pages to write"
+
+// ErrPDFWrite is the message of responses for failed writes
+const ErrPDFWrite = "failed to write searchable PDF"
+
+// Page is a page image with the layout of its text
+type Page struct {
+	Image image.Image
+	// Layout of the page, pages without layout have no text layer
+	Layout *giniapi.PageLayout
+}
+
+// Options specify parameters to the Write function
+type Options struct {
+	// Title, Author and Subject of the document information and metadata
+	Title   string
+	Author  string
+	Subject string
+	// Created is the creation date (default now)
+	Created time.Time
+	// Quality of the JPEG encoded page images (default 85)
+	Quality int
+	// DPI of page images without layout, which gives the page size (default 150)
+	DPI float64
+}
+
+func (o Options) withDefaults() Options {
+	if o.Created.IsZero() {
+		o.Created = time.Now()
+	}
+	if o.Quality <= 0 || o.Quality > 100 {
+		o.Quality = 85
+	}
+	if o.DPI <= 0 {
+		o.DPI = 150
+	}
+	return o
+}
+
+// Write writes the pages as a searchable PDF. The page size is taken from
+// the layout (SizeX, SizeY in points), the image is stretched to fill the
+// page. Every word is scaled horizontally to cover its box, so selections
+// match the words of the image.
+func Write(w io.Writer, pages []Page, options Options) error {
+	if len(pages) == 0 {
+		return errors.New(ErrNoPages)
+	}
+
+	options = options.withDefaults()
+
+	d := newDocument(w)
+	return d.write(pages, options)
+}
+
+// FromDocument downloads the page images of a document in the given size
+// (empty for the largest) and writes them as searchable PDF with the text of
+// the layout. The layout is fetched if nil.
+func FromDocument(ctx context.Context, doc *giniapi.Document, layout *giniapi.Layout, size string, w io.Writer, options Options) giniapi.APIResponse {
+	var resp giniapi.APIResponse
+
+	if layout == nil {
+		if layout, resp = doc.GetLayout(ctx); resp.Error != nil {
+			return resp
+		}
+	}
+
+	numbers := make([]int, len(doc.Pages))
+	for i, page := range doc.Pages {
+		numbers[i] = page.PageNumber
+	}
+	sort.Ints(numbers)
+
+	pages := make([]Page, 0, len(numbers))
+	for _, number := range numbers {
+		var img image.Image
+		if img, resp = doc.GetPageImage(ctx, number, size); resp.Error != nil {
+			return resp
+		}
+		pages = append(pages, Page{Image: img, Layout: layout.Page(number)})
+	}
+
+	if err := Write(w, pages, options); err != nil {
+		return giniapi.APIResponse{
+			Message:    fmt.Sprintf("%s: %s", ErrPDFWrite, err),
+			DocumentId: doc.ID,
+			Error:      err,
+		}
+	}
+
+	resp.Message = "searchable PDF completed"
+	return resp
+}
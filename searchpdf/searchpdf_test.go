@@ -0,0 +1,217 @@
+package searchpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkerwin/gini-api-go"
+)
+
+func testLayout() *giniapi.PageLayout {
+	word := func(text string, left, top, width float64) giniapi.Word {
+		return giniapi.Word{
+			PageCoordinates: giniapi.PageCoordinates{L: left, T: top, W: width, H: 10},
+			Fontsize:        10,
+			Text:            text,
+		}
+	}
+
+	return &giniapi.PageLayout{
+		Number: 1,
+		SizeX:  595.3,
+		SizeY:  841.9,
+		TextZones: []giniapi.TextZone{{Paragraphs: []giniapi.Paragraph{{Lines: []giniapi.Line{{
+			Words: []giniapi.Word{word("Rechnung", 50, 100, 60), word("(Kopie)", 115, 100, 40), word("Größe", 160, 100, 30)},
+		}}}}}},
+	}
+}
+
+// testStreams returns the decompressed data of all flate streams
+func testStreams(t *testing.T, pdf []byte) []string {
+	var streams []string
+	for _, match := range regexp.MustCompile(`(?s)/FlateDecode /Length (\d+) >>\nstream\n`).FindAllSubmatchIndex(pdf, -1) {
+		length, _ := strconv.Atoi(string(pdf[match[2]:match[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(pdf[match[1] : match[1]+length]))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		data, _ := ioutil.ReadAll(r)
+		streams = append(streams, string(data))
+	}
+	return streams
+}
+
+// testXref checks that every offset of the cross reference table points to
+// its object
+func testXref(t *testing.T, pdf []byte) {
+	start := bytes.LastIndex(pdf, []byte("startxref\n"))
+	offset, err := strconv.Atoi(strings.Fields(string(pdf[start+10:]))[0])
+	if err != nil || !bytes.HasPrefix(pdf[offset:], []byte("xref\n")) {
+		t.Fatalf("Invalid startxref")
+	}
+
+	lines := strings.Split(string(pdf[offset:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for number := 1; number < count; number++ {
+		entry := lines[2+number]
+		if len(entry) != 19 {
+			t.Fatalf("Invalid xref entry %q", entry)
+		}
+		position, _ := strconv.Atoi(entry[:10])
+		if !bytes.HasPrefix(pdf[position:], []byte(fmt.Sprintf("%d 0 obj\n", number))) {
+			t.Errorf("Object %d not at offset %d", number, position)
+		}
+	}
+}
+
+func Test_Write(t *testing.T) {
+	var b bytes.Buffer
+	pages := []Page{
+		{Image: image.NewRGBA(image.Rect(0, 0, 124, 175)), Layout: testLayout()},
+		{Image: image.NewGray(image.Rect(0, 0, 300, 150))},
+	}
+	created := time.Date(2018, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	if err := Write(&b, pages, Options{Title: "Rechnung März", Created: created}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	pdf := b.Bytes()
+	testXref(t, pdf)
+
+	for _, expected := range []string{
+		"%PDF-1.7\n",
+		"/Type /Pages /Kids [7 0 R 10 0 R] /Count 2",
+		"/MediaBox [0 0 595.3 841.9]",
+		"/MediaBox [0 0 144 72]",
+		"/ColorSpace /DeviceRGB",
+		"/ColorSpace /DeviceGray",
+		"/S /GTS_PDFA1 /OutputConditionIdentifier (sRGB IEC61966-2.1)",
+		"/Title <FEFF0052006500630068006E0075006E00670020004D00E40072007A>",
+		"/CreationDate (D:20180301123000+01'00')",
+		"<pdfaid:part>2</pdfaid:part>",
+		"<xmp:CreateDate>2018-03-01T12:30:00+01:00</xmp:CreateDate>",
+		"<rdf:li xml:lang=\"x-default\">Rechnung März</rdf:li>",
+		"/BaseFont /Helvetica /Encoding /WinAnsiEncoding",
+		"%%EOF\n",
+	} {
+		if !bytes.Contains(pdf, []byte(expected)) {
+			t.Errorf("Missing %q", expected)
+		}
+	}
+
+	streams := testStreams(t, pdf)
+	if len(streams) != 2 {
+		t.Fatalf("Expected 2 content streams, got %d", len(streams))
+	}
+
+	for _, expected := range []string{
+		"q 595.3 0 0 841.9 0 0 cm /Im1 Do Q\n",
+		"BT 3 Tr\n",
+		"/F1 10 Tf 131.64 Tz 1 0 0 1 50 733.97 Tm (Rechnung) Tj\n",
+		"(\\(Kopie\\)) Tj\n",
+		"(Gr\xf6\xdfe) Tj\n",
+	} {
+		if !strings.Contains(streams[0], expected) {
+			t.Errorf("Missing %q in text layer:\n%s", expected, streams[0])
+		}
+	}
+
+	if streams[1] != "q 144 0 0 72 0 0 cm /Im1 Do Q\n" {
+		t.Errorf("Unexpected content of page without layout: %q", streams[1])
+	}
+
+	if err := Write(&b, nil, Options{}); err == nil || err.Error() != ErrNoPages {
+		t.Errorf("Expected ErrNoPages, got %v", err)
+	}
+	if err := Write(&b, []Page{{Layout: testLayout()}}, Options{}); err == nil {
+		t.Errorf("Expected error for a page without image")
+	}
+}
+
+func Test_srgbProfile(t *testing.T) {
+	profile := srgbProfile()
+
+	size := int(profile[0])<<24 | int(profile[1])<<16 | int(profile[2])<<8 | int(profile[3])
+	if size != len(profile) || size%4 != 0 {
+		t.Errorf("Unexpected profile size %d of %d bytes", size, len(profile))
+	}
+	if string(profile[36:40]) != "acsp" {
+		t.Errorf("Missing profile signature")
+	}
+	if tags := profile[131]; tags != 9 {
+		t.Errorf("Expected 9 tags, got %d", tags)
+	}
+}
+
+func Test_FromDocument(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/documents/1234":
+			fmt.Fprintf(w, `{"id": "1234", "pageCount": 2, "_links": {"layout": "%[1]s/documents/1234/layout"}, "pages": [
+				{"pageNumber": 2, "images": {"100x140": "%[1]s/pages/2"}},
+				{"pageNumber": 1, "images": {"100x140": "%[1]s/pages/1"}}
+			]}`, server.URL)
+		case "/documents/1234/layout":
+			fmt.Fprint(w, `{"pages": [{"number": 1, "sizeX": 595.3, "sizeY": 841.9, "textZones": [{"paragraphs": [{"lines": [
+				{"wds": [{"l": 50, "t": 100, "w": 60, "h": 10, "text": "Rechnung"}]}
+			]}]}]}]}`)
+		case "/pages/1", "/pages/2":
+			png.Encode(w, image.NewRGBA(image.Rect(0, 0, 100, 140)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := giniapi.NewClient(&giniapi.Config{
+		ClientID:       "testclient",
+		ClientSecret:   "secret",
+		Authentication: giniapi.UseBasicAuth,
+		Endpoints:      giniapi.Endpoints{API: server.URL, UserCenter: server.URL},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	ctx := context.Background()
+	doc, resp := client.Get(ctx, server.URL+"/documents/1234", "user123")
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %s", resp.Error)
+	}
+
+	var b bytes.Buffer
+	resp = FromDocument(ctx, doc, nil, "", &b, Options{})
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %s", resp.Error)
+	}
+
+	pdf := b.Bytes()
+	testXref(t, pdf)
+
+	// page 2 has no layout and is sized from the image
+	if !bytes.Contains(pdf, []byte("/MediaBox [0 0 595.3 841.9]")) || !bytes.Contains(pdf, []byte("/MediaBox [0 0 48 67.2]")) {
+		t.Errorf("Unexpected page sizes")
+	}
+	if streams := testStreams(t, pdf); len(streams) != 2 || !strings.Contains(streams[0], "(Rechnung) Tj") {
+		t.Errorf("Unexpected content streams: %q", streams)
+	}
+
+	doc.Pages[0].Images = nil
+	if resp = FromDocument(ctx, doc, nil, "", &b, Options{}); resp.Error == nil {
+		t.Errorf("Expected error for a missing page image")
+	}
+}
@@ -0,0 +1,333 @@
+package searchpdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf16"
+
+	"github.com/dkerwin/gini-api-go"
+)
+
+// outputCondition identifies the color space of the output intent
+const outputCondition = "sRGB IEC61966-2.1"
+
+// producer is recorded in the document information and metadata
+const producer = "gini-api-go searchpdf"
+
+// fixed object numbers, the objects of page i follow at firstPageObject+3*i
+const (
+	catalogObject = iota + 1
+	pagesObject
+	infoObject
+	metadataObject
+	profileObject
+	fontObject
+	firstPageObject
+)
+
+// document writes the objects of a PDF and keeps their offsets for the
+// cross reference table. The first write error is kept and ends all output.
+type document struct {
+	w       io.Writer
+	offset  int64
+	offsets map[int]int64
+	err     error
+}
+
+func newDocument(w io.Writer) *document {
+	return &document{w: w, offsets: map[int]int64{}}
+}
+
+func (d *document) Write(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	n, err := d.w.Write(p)
+	d.offset += int64(n)
+	d.err = err
+	return n, err
+}
+
+func (d *document) printf(format string, args ...interface{}) {
+	fmt.Fprintf(d, format, args...)
+}
+
+// object writes an object with a dictionary or other direct value
+func (d *document) object(number int, value string) {
+	d.offsets[number] = d.offset
+	d.printf("%d 0 obj\n%s\nendobj\n", number, value)
+}
+
+// stream writes a stream object, dict holds the entries besides /Length
+func (d *document) stream(number int, dict string, data []byte) {
+	d.offsets[number] = d.offset
+	d.printf("%d 0 obj\n<< %s /Length %d >>\nstream\n", number, dict, len(data))
+	d.Write(data)
+	d.printf("\nendstream\nendobj\n")
+}
+
+func (d *document) write(pages []Page, options Options) error {
+	// the binary comment marks the file as binary for transfer programs
+	d.printf("%%PDF-1.7\n%%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObject+3*i)
+	}
+
+	d.object(catalogObject, fmt.Sprintf(
+		"<< /Type /Catalog /Pages %d 0 R /Metadata %d 0 R /OutputIntents [<< /Type /OutputIntent /S /GTS_PDFA1 "+
+			"/OutputConditionIdentifier %s /Info %s /DestOutputProfile %d 0 R >>] >>",
+		pagesObject, metadataObject, literal(outputCondition), literal(outputCondition), profileObject))
+	d.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", join(kids), len(pages)))
+	d.object(infoObject, info(options))
+	d.stream(metadataObject, "/Type /Metadata /Subtype /XML", metadata(options))
+	d.stream(profileObject, "/N 3", srgbProfile())
+	d.object(fontObject, fontDictionary())
+
+	for i, page := range pages {
+		if err := d.page(firstPageObject+3*i, page, options); err != nil {
+			return err
+		}
+	}
+
+	xref := d.offset
+	count := firstPageObject + 3*len(pages)
+	d.printf("xref\n0 %d\n0000000000 65535 f \n", count)
+	for number := 1; number < count; number++ {
+		d.printf("%010d 00000 n \n", d.offsets[number])
+	}
+
+	id := md5.Sum([]byte(fmt.Sprintf("%s|%s|%d", options.Title, options.Created.Format(time.RFC3339Nano), len(pages))))
+	d.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R /ID [<%x> <%x>] >>\nstartxref\n%d\n%%%%EOF\n",
+		count, catalogObject, infoObject, id, id, xref)
+
+	return d.err
+}
+
+// page writes the page object, its content stream and the page image
+func (d *document) page(number int, page Page, options Options) error {
+	if page.Image == nil {
+		return fmt.Errorf("page %d has no image", (number-firstPageObject)/3+1)
+	}
+
+	bounds := page.Image.Bounds()
+	width := float64(bounds.Dx()) * 72 / options.DPI
+	height := float64(bounds.Dy()) * 72 / options.DPI
+	if page.Layout != nil && page.Layout.SizeX > 0 && page.Layout.SizeY > 0 {
+		width, height = page.Layout.SizeX, page.Layout.SizeY
+	}
+
+	contents, img := number+1, number+2
+
+	d.object(number, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> "+
+			"/XObject << /Im1 %d 0 R >> >> /Contents %d 0 R >>",
+		pagesObject, number2(width), number2(height), fontObject, img, contents))
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "q %s 0 0 %s 0 0 cm /Im1 Do Q\n", number2(width), number2(height))
+	if page.Layout != nil {
+		writeText(&content, page.Layout, height)
+	}
+
+	compressed, err := deflate(content.Bytes())
+	if err != nil {
+		return err
+	}
+	d.stream(contents, "/Filter /FlateDecode", compressed)
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, page.Image, &jpeg.Options{Quality: options.Quality}); err != nil {
+		return err
+	}
+
+	colorSpace := "/DeviceRGB"
+	if _, ok := page.Image.(*image.Gray); ok {
+		colorSpace = "/DeviceGray"
+	}
+
+	d.stream(img, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s "+
+		"/BitsPerComponent 8 /Filter /DCTDecode", bounds.Dx(), bounds.Dy(), colorSpace), encoded.Bytes())
+
+	return d.err
+}
+
+// writeText writes the words of the layout as invisible text. The font size
+// is the word height, the baseline sits at the descent above the bottom of
+// the box and the horizontal scaling stretches the word to the box width.
+func writeText(w io.Writer, layout *giniapi.PageLayout, pageHeight float64) {
+	fmt.Fprint(w, "BT 3 Tr\n")
+	for _, zone := range layout.TextZones {
+		for _, paragraph := range zone.Paragraphs {
+			for _, line := range paragraph.Lines {
+				for _, word := range line.Words {
+					encoded := encodeWinAnsi(word.Text)
+					natural := float64(textWidth(encoded)) * word.H / 1000
+					if word.W <= 0 || word.H <= 0 || natural == 0 {
+						continue
+					}
+					fmt.Fprintf(w, "/F1 %s Tf %s Tz 1 0 0 1 %s %s Tm %s Tj\n",
+						number2(word.H), number2(100*word.W/natural),
+						number2(word.L), number2(pageHeight-word.Bottom()+helveticaDescent*word.H),
+						literalBytes(encoded))
+				}
+			}
+		}
+	}
+	fmt.Fprint(w, "ET\n")
+}
+
+// helveticaDescent is the depth of the descender in units of the font size
+const helveticaDescent = 0.207
+
+// fontDictionary returns the standard font of the text layer. PDF/A does not
+// require embedding fonts only used for invisible text.
+func fontDictionary() string {
+	widths := make([]string, len(helveticaWidths))
+	for i, width := range helveticaWidths {
+		widths[i] = strconv.Itoa(width)
+	}
+	return fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding "+
+		"/FirstChar 32 /LastChar 255 /Widths [%s] >>", join(widths))
+}
+
+// info returns the document information dictionary
+func info(options Options) string {
+	var b bytes.Buffer
+	b.WriteString("<< ")
+	for _, entry := range []struct{ key, value string }{
+		{"Title", options.Title},
+		{"Author", options.Author},
+		{"Subject", options.Subject},
+	} {
+		if entry.value != "" {
+			fmt.Fprintf(&b, "/%s %s ", entry.key, text(entry.value))
+		}
+	}
+	date := pdfDate(options.Created)
+	fmt.Fprintf(&b, "/Producer %s /CreationDate %s /ModDate %s >>", literal(producer), literal(date), literal(date))
+	return b.String()
+}
+
+// metadata returns the XMP metadata packet, its values equal the document
+// information as PDF/A requires
+func metadata(options Options) []byte {
+	escape := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString("<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\" " +
+		"xmlns:dc=\"http://purl.org/dc/elements/1.1/\" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\" " +
+		"xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\">\n")
+	b.WriteString("<pdfaid:part>2</pdfaid:part>\n<pdfaid:conformance>B</pdfaid:conformance>\n")
+	b.WriteString("<dc:format>application/pdf</dc:format>\n")
+	if options.Title != "" {
+		fmt.Fprintf(&b, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", escape(options.Title))
+	}
+	if options.Author != "" {
+		fmt.Fprintf(&b, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", escape(options.Author))
+	}
+	if options.Subject != "" {
+		fmt.Fprintf(&b, "<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", escape(options.Subject))
+	}
+	date := options.Created.Truncate(time.Second).Format(time.RFC3339)
+	fmt.Fprintf(&b, "<xmp:CreateDate>%s</xmp:CreateDate>\n<xmp:ModifyDate>%s</xmp:ModifyDate>\n", date, date)
+	fmt.Fprintf(&b, "<pdf:Producer>%s</pdf:Producer>\n", producer)
+	b.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	return b.Bytes()
+}
+
+// pdfDate formats t as PDF date string
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("D:%s%c%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, offset/60%60)
+}
+
+// text returns s as literal string or as UTF-16 hex string if it contains
+// characters outside of ASCII
+func text(s string) string {
+	for _, r := range s {
+		if r > 0x7E {
+			var b bytes.Buffer
+			b.WriteString("<FEFF")
+			for _, unit := range utf16.Encode([]rune(s)) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">")
+			return b.String()
+		}
+	}
+	return literal(s)
+}
+
+func literal(s string) string {
+	return literalBytes([]byte(s))
+}
+
+// literalBytes returns a literal string with escaped delimiters and control
+// characters
+func literalBytes(s []byte) string {
+	var b bytes.Buffer
+	b.WriteByte('(')
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// number2 formats v with up to two decimals
+func number2(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func join(values []string) string {
+	var b bytes.Buffer
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(v)
+	}
+	return b.String()
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,392 @@
+// Copyright 2015-2018 The gini-api-go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package sepa builds SEPA credit transfer initiation files (pain.001.001.03)
+from the payment extractions of invoices processed by Gini.
+
+Every Extractions value becomes a single transfer. The labels iban,
+paymentRecipient and amountToPay are required, bic and paymentReference are
+optional. All transfers of a CreditTransfer are debited from the same account
+and written as one payment information block:
+
+	ct := sepa.CreditTransfer{
+		InitiatingParty: "ACME Corp",
+		Debtor:          sepa.Account{Name: "ACME Corp", IBAN: "DE89370400440532013000"},
+	}
+
+	if err := ct.AddExtractions(extractions); err != nil {
+		log.Printf("invalid payment data: %s", err)
+	}
+
+	ct.WriteXML(os.Stdout)
+*/
+package sepa
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dkerwin/gini-api-go"
+)
+
+const (
+	// Namespace of the generated XML documents
+	Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+	// NotProvided is used for missing end to end ids and debtor agents
+	NotProvided = "NOTPROVIDED"
+
+	maxAmountCents = 99999999999
+)
+
+// Account identifies a debtor or creditor
+type Account struct {
+	Name string
+	IBAN string
+	// BIC is optional for SEPA transfers
+	BIC string
+}
+
+// Transfer is a single credit transfer transaction
+type Transfer struct {
+	// EndToEndID is passed along to the creditor (defaults to NOTPROVIDED)
+	EndToEndID string
+	Creditor   Account
+	Amount     giniapi.Amount
+	// RemittanceInformation is the unstructured payment reference
+	RemittanceInformation string
+}
+
+// ValidationError describes a missing or malformed field
+type ValidationError struct {
+	// Transfer is the index of the transfer or -1 for the file header
+	Transfer int
+	Field    string
+	Message  string
+}
+
+// Error satisfies the error interface
+func (e ValidationError) Error() string {
+	if e.Transfer < 0 {
+		return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	}
+	return fmt.Sprintf("transfer %d: %s: %s", e.Transfer, e.Field, e.Message)
+}
+
+// ValidationErrors collects all problems found in a credit transfer
+type ValidationErrors []ValidationError
+
+// Error satisfies the error interface
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// extractionLabels maps transfer fields to the labels they are extracted from
+var extractionLabels = map[string]string{
+	"creditor.name": "paymentRecipient",
+	"creditor.iban": "iban",
+	"creditor.bic":  "bic",
+	"amount":        "amountToPay",
+	"endToEndId":    "endToEndId",
+}
+
+// TransferFromExtractions maps the payment extractions of a document to a
+// Transfer. Missing or malformed fields are returned as ValidationErrors.
+func TransferFromExtractions(e *giniapi.Extractions) (Transfer, error) {
+	var errs ValidationErrors
+
+	if e == nil {
+		return Transfer{}, append(errs, ValidationError{-1, "extractions", "missing"})
+	}
+
+	transfer := Transfer{
+		Creditor: Account{
+			Name: e.GetValue("paymentRecipient"),
+			IBAN: giniapi.NormalizeIBAN(e.GetValue("iban")),
+			BIC:  strings.ToUpper(strings.TrimSpace(e.GetValue("bic"))),
+		},
+		RemittanceInformation: e.GetValue("paymentReference"),
+	}
+
+	amountFailed := true
+	if value := e.GetValue("amountToPay"); value == "" {
+		errs = append(errs, ValidationError{-1, "amountToPay", "missing"})
+	} else if amount, err := giniapi.ParseAmount(value); err != nil {
+		errs = append(errs, ValidationError{-1, "amountToPay", err.Error()})
+	} else {
+		transfer.Amount, amountFailed = amount, false
+	}
+
+	for _, err := range transfer.validate() {
+		if err.Field == "amount" && amountFailed {
+			continue
+		}
+		err.Field = extractionLabels[err.Field]
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return transfer, errs
+	}
+
+	return transfer, nil
+}
+
+// validate checks a single transfer. Errors are reported with Transfer -1.
+func (t Transfer) validate() ValidationErrors {
+	var errs ValidationErrors
+
+	add := func(field, message string) {
+		errs = append(errs, ValidationError{-1, field, message})
+	}
+
+	if t.Creditor.Name == "" {
+		add("creditor.name", "missing")
+	}
+
+	if t.Creditor.IBAN == "" {
+		add("creditor.iban", "missing")
+	} else if err := giniapi.ValidateIBAN(t.Creditor.IBAN); err != nil {
+		add("creditor.iban", err.Error())
+	}
+
+	if t.Creditor.BIC != "" {
+		if err := giniapi.ValidateBIC(t.Creditor.BIC); err != nil {
+			add("creditor.bic", err.Error())
+		}
+	}
+
+	if t.Amount.Currency != "EUR" {
+		add("amount", fmt.Sprintf("unsupported currency %q", t.Amount.Currency))
+	} else if t.Amount.Cents <= 0 || t.Amount.Cents > maxAmountCents {
+		add("amount", "must be between 0.01 and 999999999.99")
+	}
+
+	if len(t.EndToEndID) > 35 {
+		add("endToEndId", "exceeds 35 characters")
+	}
+
+	return errs
+}
+
+// CreditTransfer is a pain.001 credit transfer initiation
+type CreditTransfer struct {
+	// MessageID identifies the file (defaults to a timestamp based id)
+	MessageID string
+	// CreationTime of the file (defaults to now)
+	CreationTime time.Time
+	// InitiatingParty is the name of the submitting party
+	InitiatingParty string
+	// Debtor account all transfers are debited from
+	Debtor Account
+	// ExecutionDate requested for all transfers (defaults to today)
+	ExecutionDate time.Time
+	// BatchBooking requests a single booking for all transfers
+	BatchBooking bool
+	Transfers    []Transfer
+}
+
+// Add appends transfers
+func (c *CreditTransfer) Add(transfers ...Transfer) {
+	c.Transfers = append(c.Transfers, transfers...)
+}
+
+// AddExtractions converts and appends the extractions of one or more
+// documents. Nothing is added if any of them is invalid. The returned
+// ValidationErrors reference the position in extractions.
+func (c *CreditTransfer) AddExtractions(extractions ...*giniapi.Extractions) error {
+	var errs ValidationErrors
+	var transfers []Transfer
+
+	for i, e := range extractions {
+		transfer, err := TransferFromExtractions(e)
+		if err != nil {
+			for _, v := range err.(ValidationErrors) {
+				v.Transfer = i
+				errs = append(errs, v)
+			}
+			continue
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	c.Add(transfers...)
+
+	return nil
+}
+
+// Validate checks header and transfers and returns ValidationErrors
+func (c *CreditTransfer) Validate() error {
+	var errs ValidationErrors
+
+	if c.InitiatingParty == "" {
+		errs = append(errs, ValidationError{-1, "initiatingParty", "missing"})
+	}
+	if c.Debtor.Name == "" {
+		errs = append(errs, ValidationError{-1, "debtor.name", "missing"})
+	}
+	if err := giniapi.ValidateIBAN(c.Debtor.IBAN); err != nil {
+		errs = append(errs, ValidationError{-1, "debtor.iban", err.Error()})
+	}
+	if c.Debtor.BIC != "" {
+		if err := giniapi.ValidateBIC(c.Debtor.BIC); err != nil {
+			errs = append(errs, ValidationError{-1, "debtor.bic", err.Error()})
+		}
+	}
+	if len(c.MessageID) > 35 {
+		errs = append(errs, ValidationError{-1, "messageId", "exceeds 35 characters"})
+	}
+	if len(c.Transfers) == 0 {
+		errs = append(errs, ValidationError{-1, "transfers", "at least one transfer required"})
+	}
+
+	for i, transfer := range c.Transfers {
+		for _, err := range transfer.validate() {
+			err.Transfer = i
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// WriteXML validates the credit transfer and writes the pain.001 document
+func (c *CreditTransfer) WriteXML(w io.Writer) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(c.document()); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// document converts the credit transfer into its XML representation
+func (c *CreditTransfer) document() *document {
+	created := c.CreationTime
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	execution := c.ExecutionDate
+	if execution.IsZero() {
+		execution = created
+	}
+
+	messageID := c.MessageID
+	if messageID == "" {
+		messageID = "MSG-" + created.Format("20060102150405.000")
+	}
+
+	var sum int64
+	transactions := make([]transaction, len(c.Transfers))
+
+	for i, t := range c.Transfers {
+		sum += t.Amount.Cents
+
+		endToEndID := t.EndToEndID
+		if endToEndID == "" {
+			endToEndID = NotProvided
+		}
+
+		transactions[i] = transaction{
+			EndToEndID: endToEndID,
+			Amount:     amount{Currency: t.Amount.Currency, Value: t.Amount.Decimal()},
+			Creditor:   party{Name: clean(t.Creditor.Name, 70)},
+			Account:    account{IBAN: giniapi.NormalizeIBAN(t.Creditor.IBAN)},
+		}
+
+		if t.Creditor.BIC != "" {
+			transactions[i].Agent = &agent{BIC: t.Creditor.BIC}
+		}
+
+		if t.RemittanceInformation != "" {
+			transactions[i].Remittance = &remittance{Unstructured: clean(t.RemittanceInformation, 140)}
+		}
+	}
+
+	debtorAgent := agent{BIC: c.Debtor.BIC}
+	if debtorAgent.BIC == "" {
+		debtorAgent.Other = NotProvided
+	}
+
+	controlSum := giniapi.Amount{Cents: sum}.Decimal()
+	count := fmt.Sprintf("%d", len(c.Transfers))
+
+	return &document{
+		Namespace: Namespace,
+		Header: groupHeader{
+			MessageID:       messageID,
+			Created:         created.Format("2006-01-02T15:04:05"),
+			NumberOfTxs:     count,
+			ControlSum:      controlSum,
+			InitiatingParty: party{Name: clean(c.InitiatingParty, 70)},
+		},
+		Payment: paymentInfo{
+			ID:            messageID,
+			Method:        "TRF",
+			BatchBooking:  c.BatchBooking,
+			NumberOfTxs:   count,
+			ControlSum:    controlSum,
+			ServiceLevel:  "SEPA",
+			ExecutionDate: execution.Format("2006-01-02"),
+			Debtor:        party{Name: clean(c.Debtor.Name, 70)},
+			DebtorAccount: account{IBAN: giniapi.NormalizeIBAN(c.Debtor.IBAN)},
+			DebtorAgent:   debtorAgent,
+			ChargeBearer:  "SLEV",
+			Transactions:  transactions,
+		},
+	}
+}
+
+// clean transliterates text to the SEPA character set and truncates it
+func clean(s string, max int) string {
+	replacer := strings.NewReplacer(
+		"Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+		"&", "+",
+	)
+	s = replacer.Replace(s)
+
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("/-?:().,'+ ", r):
+			return r
+		}
+		return ' '
+	}, s)
+
+	cleaned = strings.Join(strings.Fields(cleaned), " ")
+	if len(cleaned) > max {
+		cleaned = strings.TrimSpace(cleaned[:max])
+	}
+
+	return cleaned
+}
@@ -0,0 +1,149 @@
+package sepa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkerwin/gini-api-go"
+)
+
+func testExtractions(values map[string]string) *giniapi.Extractions {
+	e := &giniapi.Extractions{Extractions: map[string]giniapi.Extraction{}}
+	for label, value := range values {
+		e.Extractions[label] = giniapi.Extraction{Value: value}
+	}
+	return e
+}
+
+func testCreditTransfer() CreditTransfer {
+	return CreditTransfer{
+		MessageID:       "MSG-1",
+		CreationTime:    time.Date(2018, 12, 1, 10, 30, 0, 0, time.UTC),
+		ExecutionDate:   time.Date(2018, 12, 3, 0, 0, 0, 0, time.UTC),
+		InitiatingParty: "ACME Corp",
+		Debtor: Account{
+			Name: "ACME Corp",
+			IBAN: "DE89370400440532013000",
+			BIC:  "COBADEFFXXX",
+		},
+	}
+}
+
+func Test_TransferFromExtractions(t *testing.T) {
+	transfer, err := TransferFromExtractions(testExtractions(map[string]string{
+		"iban":             "DE02 1203 0000 0000 2020 51",
+		"bic":              "hyvedemmxxx",
+		"amountToPay":      "24.99:EUR",
+		"paymentRecipient": "Gini GmbH",
+		"paymentReference": "Invoice 4711",
+	}))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if transfer.Creditor.IBAN != "DE02120300000000202051" || transfer.Creditor.BIC != "HYVEDEMMXXX" {
+		t.Errorf("Creditor not normalized: %#v", transfer.Creditor)
+	}
+	if transfer.Amount.Cents != 2499 {
+		t.Errorf("Amount mismatch: %d", transfer.Amount.Cents)
+	}
+
+	_, err = TransferFromExtractions(testExtractions(map[string]string{
+		"iban":        "DE22222111117777766667",
+		"amountToPay": "24,99",
+	}))
+
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("Expected 3 validation errors, got %v", err)
+	}
+
+	fields := []string{errs[0].Field, errs[1].Field, errs[2].Field}
+	if strings.Join(fields, ",") != "amountToPay,paymentRecipient,iban" {
+		t.Errorf("Unexpected fields: %v", fields)
+	}
+}
+
+func Test_CreditTransferWriteXML(t *testing.T) {
+	ct := testCreditTransfer()
+
+	err := ct.AddExtractions(
+		testExtractions(map[string]string{
+			"iban":             "DE02120300000000202051",
+			"amountToPay":      "24.99:EUR",
+			"paymentRecipient": "Müller & Söhne",
+			"paymentReference": "Rechnung 4711",
+		}),
+		testExtractions(map[string]string{
+			"iban":             "DE89370400440532013000",
+			"bic":              "COBADEFFXXX",
+			"amountToPay":      "100.5:EUR",
+			"paymentRecipient": "Gini GmbH",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ct.WriteXML(&buf); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	out := buf.String()
+
+	for _, expected := range []string{
+		`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">`,
+		`<CreDtTm>2018-12-01T10:30:00</CreDtTm>`,
+		`<NbOfTxs>2</NbOfTxs>`,
+		`<CtrlSum>125.49</CtrlSum>`,
+		`<ReqdExctnDt>2018-12-03</ReqdExctnDt>`,
+		`<InstdAmt Ccy="EUR">24.99</InstdAmt>`,
+		`<InstdAmt Ccy="EUR">100.50</InstdAmt>`,
+		`<Nm>Mueller + Soehne</Nm>`,
+		`<Ustrd>Rechnung 4711</Ustrd>`,
+		`<EndToEndId>NOTPROVIDED</EndToEndId>`,
+		`<BIC>COBADEFFXXX</BIC>`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Missing %s in:\n%s", expected, out)
+		}
+	}
+
+	if strings.Count(out, "<CdtrAgt>") != 1 || strings.Count(out, "<RmtInf>") != 1 {
+		t.Errorf("Optional elements should only be written when set:\n%s", out)
+	}
+}
+
+func Test_CreditTransferValidate(t *testing.T) {
+	ct := CreditTransfer{}
+
+	errs, ok := ct.Validate().(ValidationErrors)
+	if !ok || len(errs) != 4 {
+		t.Fatalf("Expected 4 validation errors, got %v", errs)
+	}
+
+	ct = testCreditTransfer()
+	ct.Add(Transfer{
+		Creditor: Account{Name: "Gini GmbH", IBAN: "DE02120300000000202051"},
+		Amount:   giniapi.Amount{Cents: 100, Currency: "USD"},
+	})
+
+	err := ct.WriteXML(&bytes.Buffer{})
+	if err == nil || err.Error() != `transfer 0: amount: unsupported currency "USD"` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// invalid extractions are not added
+	err = ct.AddExtractions(testExtractions(map[string]string{"iban": "DE02120300000000202051"}))
+	if err == nil || len(ct.Transfers) != 1 {
+		t.Errorf("Invalid extractions must not be added")
+	}
+
+	err = ct.AddExtractions(nil)
+	if err == nil || err.Error() != "transfer 0: extractions: missing" {
+		t.Errorf("Unexpected error for nil extractions: %v", err)
+	}
+}
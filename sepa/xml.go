@@ -0,0 +1,67 @@
+package sepa
+
+import (
+	"encoding/xml"
+)
+
+// document is the XML root of a pain.001.001.03 file
+type document struct {
+	XMLName   xml.Name    `xml:"Document"`
+	Namespace string      `xml:"xmlns,attr"`
+	Header    groupHeader `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	Payment   paymentInfo `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type groupHeader struct {
+	MessageID       string `xml:"MsgId"`
+	Created         string `xml:"CreDtTm"`
+	NumberOfTxs     string `xml:"NbOfTxs"`
+	ControlSum      string `xml:"CtrlSum"`
+	InitiatingParty party  `xml:"InitgPty"`
+}
+
+type paymentInfo struct {
+	ID            string        `xml:"PmtInfId"`
+	Method        string        `xml:"PmtMtd"`
+	BatchBooking  bool          `xml:"BtchBookg"`
+	NumberOfTxs   string        `xml:"NbOfTxs"`
+	ControlSum    string        `xml:"CtrlSum"`
+	ServiceLevel  string        `xml:"PmtTpInf>SvcLvl>Cd"`
+	ExecutionDate string        `xml:"ReqdExctnDt"`
+	Debtor        party         `xml:"Dbtr"`
+	DebtorAccount account       `xml:"DbtrAcct"`
+	DebtorAgent   agent         `xml:"DbtrAgt"`
+	ChargeBearer  string        `xml:"ChrgBr"`
+	Transactions  []transaction `xml:"CdtTrfTxInf"`
+}
+
+type transaction struct {
+	EndToEndID string      `xml:"PmtId>EndToEndId"`
+	Amount     amount      `xml:"Amt>InstdAmt"`
+	Agent      *agent      `xml:"CdtrAgt,omitempty"`
+	Creditor   party       `xml:"Cdtr"`
+	Account    account     `xml:"CdtrAcct"`
+	Remittance *remittance `xml:"RmtInf,omitempty"`
+}
+
+type party struct {
+	Name string `xml:"Nm"`
+}
+
+type account struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+type agent struct {
+	BIC   string `xml:"FinInstnId>BIC,omitempty"`
+	Other string `xml:"FinInstnId>Othr>Id,omitempty"`
+}
+
+type amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type remittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
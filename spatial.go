@@ -0,0 +1,271 @@
+package giniapi
+
+import (
+	"math"
+	"sort"
+)
+
+// indexCellSize is the edge length of the grid cells in page units
+const indexCellSize = 50.0
+
+// LayoutIndex answers geometric queries on the words of all pages. It is
+// built once and safe for concurrent use.
+type LayoutIndex struct {
+	pages map[int]*PageIndex
+}
+
+// Index builds the spatial index of all pages
+func (l *Layout) Index() *LayoutIndex {
+	index := &LayoutIndex{pages: make(map[int]*PageIndex, len(l.Pages))}
+	for i := range l.Pages {
+		index.pages[l.Pages[i].Number] = l.Pages[i].Index()
+	}
+	return index
+}
+
+// Page returns the index of the page with the given number or nil
+func (x *LayoutIndex) Page(number int) *PageIndex {
+	return x.pages[number]
+}
+
+// WordsInBox returns the words intersecting an extraction box
+func (x *LayoutIndex) WordsInBox(box Box) []Word {
+	if page := x.Page(box.Page); page != nil {
+		return page.WordsIn(box.Coordinates())
+	}
+	return nil
+}
+
+// LinesInBox returns the lines intersecting an extraction box
+func (x *LayoutIndex) LinesInBox(box Box) []Line {
+	if page := x.Page(box.Page); page != nil {
+		return page.LinesIn(box.Coordinates())
+	}
+	return nil
+}
+
+// PageIndex is a grid index over the words and lines of a page
+type PageIndex struct {
+	page      *PageLayout
+	words     []Word
+	lines     []Line
+	wordLines []int
+	columns   int
+	rows      int
+	wordCells [][]int
+	lineCells [][]int
+}
+
+// Index builds the spatial index of the page. Words and lines are returned
+// in layout order by all queries.
+func (p *PageLayout) Index() *PageIndex {
+	x := &PageIndex{page: p}
+
+	width, height := p.SizeX, p.SizeY
+	for _, zone := range p.TextZones {
+		for _, paragraph := range zone.Paragraphs {
+			for _, line := range paragraph.Lines {
+				x.lines = append(x.lines, line)
+				for _, word := range line.Words {
+					x.words = append(x.words, word)
+					x.wordLines = append(x.wordLines, len(x.lines)-1)
+				}
+				width = math.Max(width, line.Right())
+				height = math.Max(height, line.Bottom())
+			}
+		}
+	}
+
+	x.columns = int(width/indexCellSize) + 1
+	x.rows = int(height/indexCellSize) + 1
+	x.wordCells = make([][]int, x.columns*x.rows)
+	x.lineCells = make([][]int, x.columns*x.rows)
+
+	for i, word := range x.words {
+		x.cells(word.PageCoordinates, func(cell int) {
+			x.wordCells[cell] = append(x.wordCells[cell], i)
+		})
+	}
+	for i, line := range x.lines {
+		x.cells(line.PageCoordinates, func(cell int) {
+			x.lineCells[cell] = append(x.lineCells[cell], i)
+		})
+	}
+
+	return x
+}
+
+// cells calls fn for every grid cell c touches
+func (x *PageIndex) cells(c PageCoordinates, fn func(cell int)) {
+	cell := func(v float64, limit int) int {
+		i := int(math.Floor(v / indexCellSize))
+		if i < 0 {
+			return 0
+		}
+		if i >= limit {
+			return limit - 1
+		}
+		return i
+	}
+
+	left, right := cell(c.L, x.columns), cell(c.Right(), x.columns)
+	top, bottom := cell(c.T, x.rows), cell(c.Bottom(), x.rows)
+
+	for row := top; row <= bottom; row++ {
+		for column := left; column <= right; column++ {
+			fn(row*x.columns + column)
+		}
+	}
+}
+
+// query returns the sorted indexes in cells touched by c matching fn
+func (x *PageIndex) query(cells [][]int, c PageCoordinates, fn func(i int) bool) []int {
+	seen := map[int]bool{}
+	var found []int
+
+	x.cells(c, func(cell int) {
+		for _, i := range cells[cell] {
+			if !seen[i] {
+				seen[i] = true
+				if fn(i) {
+					found = append(found, i)
+				}
+			}
+		}
+	})
+
+	sort.Ints(found)
+	return found
+}
+
+// WordsIn returns the words intersecting c
+func (x *PageIndex) WordsIn(c PageCoordinates) []Word {
+	var words []Word
+	for _, i := range x.query(x.wordCells, c, func(i int) bool { return x.words[i].Intersects(c) }) {
+		words = append(words, x.words[i])
+	}
+	return words
+}
+
+// LinesIn returns the lines intersecting c
+func (x *PageIndex) LinesIn(c PageCoordinates) []Line {
+	var lines []Line
+	for _, i := range x.query(x.lineCells, c, func(i int) bool { return x.lines[i].Intersects(c) }) {
+		lines = append(lines, x.lines[i])
+	}
+	return lines
+}
+
+// WordsLeftOf returns the words on the same line height left of w within
+// maxDistance, nearest first. This finds the label of a value, e.g. "IBAN:".
+func (x *PageIndex) WordsLeftOf(w Word, maxDistance float64) []Word {
+	area := PageCoordinates{L: w.L - maxDistance, T: w.T, W: maxDistance, H: w.H}
+
+	found := x.query(x.wordCells, area, func(i int) bool {
+		other := x.words[i]
+		return other != w && other.Right() <= w.L+tolerance(w, other) &&
+			w.L-other.Right() <= maxDistance && verticalOverlap(w.PageCoordinates, other.PageCoordinates)
+	})
+
+	return x.nearest(found, func(other Word) float64 { return w.L - other.Right() })
+}
+
+// WordsAbove returns the words above w overlapping it horizontally within
+// maxDistance, nearest first. This finds column headers.
+func (x *PageIndex) WordsAbove(w Word, maxDistance float64) []Word {
+	area := PageCoordinates{L: w.L, T: w.T - maxDistance, W: w.W, H: maxDistance}
+
+	found := x.query(x.wordCells, area, func(i int) bool {
+		other := x.words[i]
+		return other != w && other.Bottom() <= w.T+tolerance(w, other) &&
+			w.T-other.Bottom() <= maxDistance && other.L < w.Right() && w.L < other.Right()
+	})
+
+	return x.nearest(found, func(other Word) float64 { return w.T - other.Bottom() })
+}
+
+// WordsInRegion returns the words inside any region of the given type, e.g.
+// "RemittanceSlip"
+func (x *PageIndex) WordsInRegion(regionType string) []Word {
+	var found []int
+	for _, region := range x.page.Regions {
+		if region.Type == regionType {
+			found = append(found, x.query(x.wordCells, region.PageCoordinates, func(i int) bool {
+				return region.Contains(x.words[i].PageCoordinates)
+			})...)
+		}
+	}
+
+	sort.Ints(found)
+	var words []Word
+	for i, index := range found {
+		if i == 0 || found[i-1] != index {
+			words = append(words, x.words[index])
+		}
+	}
+	return words
+}
+
+// Nearest returns up to n words closest to c, nearest first. Words
+// intersecting c have a distance of 0.
+func (x *PageIndex) Nearest(c PageCoordinates, n int) []Word {
+	if n <= 0 || len(x.words) == 0 {
+		return nil
+	}
+
+	// grow the search area until enough words are found within the radius
+	for radius := indexCellSize; ; radius *= 2 {
+		area := PageCoordinates{L: c.L - radius, T: c.T - radius, W: c.W + 2*radius, H: c.H + 2*radius}
+		found := x.query(x.wordCells, area, func(i int) bool { return rectDistance(c, x.words[i].PageCoordinates) <= radius })
+
+		covers := area.L <= 0 && area.T <= 0 && area.Right() >= float64(x.columns)*indexCellSize && area.Bottom() >= float64(x.rows)*indexCellSize
+		if len(found) >= n || covers {
+			words := x.nearest(found, func(other Word) float64 { return rectDistance(c, other.PageCoordinates) })
+			if len(words) > n {
+				words = words[:n]
+			}
+			return words
+		}
+	}
+}
+
+// Line returns the line containing w
+func (x *PageIndex) Line(w Word) (Line, bool) {
+	found := x.query(x.wordCells, w.PageCoordinates, func(i int) bool { return x.words[i] == w })
+	if len(found) == 0 {
+		return Line{}, false
+	}
+	return x.lines[x.wordLines[found[0]]], true
+}
+
+// nearest returns the words ordered by distance, ties in layout order
+func (x *PageIndex) nearest(indexes []int, distance func(Word) float64) []Word {
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return distance(x.words[indexes[i]]) < distance(x.words[indexes[j]])
+	})
+
+	words := make([]Word, len(indexes))
+	for i, index := range indexes {
+		words[i] = x.words[index]
+	}
+	return words
+}
+
+// verticalOverlap reports whether a and b share at least half of the height
+// of the smaller one
+func verticalOverlap(a, b PageCoordinates) bool {
+	overlap := math.Min(a.Bottom(), b.Bottom()) - math.Max(a.T, b.T)
+	return overlap >= math.Min(a.H, b.H)/2
+}
+
+// tolerance allows neighbouring words to touch or overlap slightly
+func tolerance(a, b Word) float64 {
+	return math.Min(a.H, b.H) / 4
+}
+
+// rectDistance returns the distance between the closest points of a and b
+func rectDistance(a, b PageCoordinates) float64 {
+	dx := math.Max(0, math.Max(a.L-b.Right(), b.L-a.Right()))
+	dy := math.Max(0, math.Max(a.T-b.Bottom(), b.T-a.Bottom()))
+	return math.Hypot(dx, dy)
+}
@@ -0,0 +1,82 @@
+package giniapi
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testInvoicePage is a page with labels left of and above their values
+func testInvoicePage() *PageLayout {
+	return &PageLayout{
+		Number: 1,
+		SizeX:  595,
+		SizeY:  842,
+		TextZones: []TextZone{{Paragraphs: []Paragraph{
+			testParagraph(20, 20, "Gini GmbH", "Tumblingerstr. 32"),
+			testParagraph(300, 100, "Menge Preis"),
+			testParagraph(300, 112, "2 24,99"),
+			testParagraph(20, 700, "IBAN: DE02120300000000202051"),
+			testParagraph(20, 712, "BIC: BYLADEM1001"),
+		}}},
+		Regions: []Region{
+			{PageCoordinates: PageCoordinates{L: 10, T: 690, W: 500, H: 40}, Type: "RemittanceSlip"},
+		},
+	}
+}
+
+func wordTexts(words []Word) string {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.Text
+	}
+	return fmt.Sprint(texts)
+}
+
+func Test_PageIndexWordsIn(t *testing.T) {
+	index := testInvoicePage().Index()
+
+	assertEqual(t, wordTexts(index.WordsIn(PageCoordinates{L: 0, T: 15, W: 100, H: 10})), "[Gini GmbH]", "")
+	assertEqual(t, wordTexts(index.WordsIn(PageCoordinates{L: 0, T: 695, W: 595, H: 30})), "[IBAN: DE02120300000000202051 BIC: BYLADEM1001]", "")
+	assertEqual(t, len(index.WordsIn(PageCoordinates{L: 400, T: 400, W: 10, H: 10})), 0, "")
+	assertEqual(t, len(index.LinesIn(PageCoordinates{L: 0, T: 0, W: 595, H: 842})), 6, "")
+}
+
+func Test_LayoutIndexBox(t *testing.T) {
+	layout := Layout{Pages: []PageLayout{*testInvoicePage()}}
+	index := layout.Index()
+
+	box := Box{Page: 1, Left: 80, Top: 701, Width: 100, Height: 5}
+	assertEqual(t, wordTexts(index.WordsInBox(box)), "[DE02120300000000202051]", "")
+	assertEqual(t, index.LinesInBox(box)[0].Text(), "IBAN: DE02120300000000202051", "")
+
+	box.Page = 2
+	assertEqual(t, len(index.WordsInBox(box)), 0, "unknown page")
+
+	assertEqual(t, len(testLayout(t).Index().WordsInBox(Box{Page: 1, Left: 60, Top: 160, Width: 20, Height: 5})), 2, "")
+}
+
+func Test_PageIndexNeighbours(t *testing.T) {
+	index := testInvoicePage().Index()
+	iban := index.WordsIn(PageCoordinates{L: 100, T: 701, W: 1, H: 1})[0]
+	price := index.WordsIn(PageCoordinates{L: 330, T: 113, W: 1, H: 1})[0]
+
+	assertEqual(t, wordTexts(index.WordsLeftOf(iban, 100)), "[IBAN:]", "")
+	assertEqual(t, len(index.WordsLeftOf(iban, 1)), 0, "too far")
+	assertEqual(t, wordTexts(index.WordsLeftOf(price, 50)), "[2]", "")
+	assertEqual(t, wordTexts(index.WordsAbove(price, 20)), "[Menge Preis]", "")
+	assertEqual(t, len(index.WordsAbove(iban, 20)), 0, "")
+
+	line, ok := index.Line(price)
+	assertEqual(t, ok, true, "")
+	assertEqual(t, line.Text(), "2 24,99", "")
+}
+
+func Test_PageIndexRegionAndNearest(t *testing.T) {
+	index := testInvoicePage().Index()
+
+	assertEqual(t, wordTexts(index.WordsInRegion("RemittanceSlip")), "[IBAN: DE02120300000000202051 BIC: BYLADEM1001]", "")
+	assertEqual(t, len(index.WordsInRegion("Unknown")), 0, "")
+
+	assertEqual(t, wordTexts(index.Nearest(PageCoordinates{L: 290, T: 90}, 2)), "[Menge 2]", "")
+	assertEqual(t, len(index.Nearest(PageCoordinates{L: 1000, T: 1000}, 100)), 12, "all words")
+}
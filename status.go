@@ -0,0 +1,79 @@
+package giniapi
+
+// Progress is the processing state of a document. Values unknown to this
+// package are kept as received.
+type Progress string
+
+// processing states of documents
+const (
+	ProgressPending   Progress = "PENDING"
+	ProgressCompleted Progress = "COMPLETED"
+	ProgressError     Progress = "ERROR"
+)
+
+// IsDone reports whether the processing has finished, successful or not
+func (p Progress) IsDone() bool {
+	return p == ProgressCompleted || p == ProgressError
+}
+
+// IsFailed reports whether the processing has failed
+func (p Progress) IsFailed() bool {
+	return p == ProgressError
+}
+
+// IsKnown reports whether p is one of the processing states above
+func (p Progress) IsKnown() bool {
+	switch p {
+	case ProgressPending, ProgressCompleted, ProgressError:
+		return true
+	}
+	return false
+}
+
+// Origin tells how a document was created. Values unknown to this package
+// are kept as received.
+type Origin string
+
+// origins of documents
+const (
+	OriginUpload  Origin = "UPLOAD"
+	OriginUnknown Origin = "UNKNOWN"
+)
+
+// IsKnown reports whether o is one of the origins above
+func (o Origin) IsKnown() bool {
+	return o == OriginUpload || o == OriginUnknown
+}
+
+// SourceClassification is the kind of a document's source. Values unknown
+// to this package are kept as received.
+type SourceClassification string
+
+// source classifications of documents
+const (
+	SourceScanned      SourceClassification = "SCANNED"
+	SourceScannedMulti SourceClassification = "SCANNED_MULTI"
+	SourceNative       SourceClassification = "NATIVE"
+	SourceText         SourceClassification = "TEXT"
+	SourceComposite    SourceClassification = "COMPOSITE"
+)
+
+// IsNative reports whether the document is a native (born digital) PDF
+func (s SourceClassification) IsNative() bool {
+	return s == SourceNative
+}
+
+// IsScanned reports whether the document consists of scanned or
+// photographed pages
+func (s SourceClassification) IsScanned() bool {
+	return s == SourceScanned || s == SourceScannedMulti
+}
+
+// IsKnown reports whether s is one of the source classifications above
+func (s SourceClassification) IsKnown() bool {
+	switch s {
+	case SourceScanned, SourceScannedMulti, SourceNative, SourceText, SourceComposite:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package giniapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_Progress(t *testing.T) {
+	assertEqual(t, ProgressPending.IsDone(), false, "")
+	assertEqual(t, ProgressCompleted.IsDone(), true, "")
+	assertEqual(t, ProgressCompleted.IsFailed(), false, "")
+	assertEqual(t, ProgressError.IsDone(), true, "")
+	assertEqual(t, ProgressError.IsFailed(), true, "")
+	assertEqual(t, Progress("ARCHIVED").IsKnown(), false, "")
+	assertEqual(t, Progress("ARCHIVED").IsDone(), false, "")
+}
+
+func Test_SourceClassification(t *testing.T) {
+	assertEqual(t, SourceNative.IsNative(), true, "")
+	assertEqual(t, SourceNative.IsScanned(), false, "")
+	assertEqual(t, SourceScanned.IsScanned(), true, "")
+	assertEqual(t, SourceScannedMulti.IsScanned(), true, "")
+	assertEqual(t, SourceComposite.IsKnown(), true, "")
+	assertEqual(t, SourceClassification("HANDWRITTEN").IsKnown(), false, "")
+	assertEqual(t, OriginUpload.IsKnown(), true, "")
+	assertEqual(t, Origin("EMAIL").IsKnown(), false, "")
+}
+
+func Test_DocumentStatusJSON(t *testing.T) {
+	var doc Document
+	err := json.Unmarshal([]byte(`{"progress": "COMPLETED", "origin": "EMAIL", "sourceClassification": "HANDWRITTEN"}`), &doc)
+
+	assertEqual(t, err, nil, "")
+	assertEqual(t, doc.Progress, ProgressCompleted, "")
+	assertEqual(t, doc.Progress.IsDone(), true, "")
+	assertEqual(t, doc.Origin, Origin("EMAIL"), "")
+	assertEqual(t, doc.SourceClassification, SourceClassification("HANDWRITTEN"), "")
+
+	// unknown values are written back unchanged
+	var fields map[string]interface{}
+	data, _ := json.Marshal(&doc)
+	json.Unmarshal(data, &fields)
+
+	assertEqual(t, fields["origin"], "EMAIL", "")
+	assertEqual(t, fields["sourceClassification"], "HANDWRITTEN", "")
+	assertEqual(t, fields["progress"], "COMPLETED", "")
+}
@@ -0,0 +1,374 @@
+package giniapi
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TableOptions specify parameters to the DetectTables function
+type TableOptions struct {
+	// MinRows is the minimum number of body rows of a table (default 2)
+	MinRows int
+	// MinColumns is the minimum number of columns of a table (default 3)
+	MinColumns int
+}
+
+func (o TableOptions) withDefaults() TableOptions {
+	if o.MinRows <= 0 {
+		o.MinRows = 2
+	}
+	if o.MinColumns <= 0 {
+		o.MinColumns = 3
+	}
+	return o
+}
+
+// Cell is a table cell with the words it consists of
+type Cell struct {
+	PageCoordinates
+	Text  string
+	Words []Word
+}
+
+// Table is a grid of cells detected in the layout of a page
+type Table struct {
+	PageCoordinates
+	// Page number of the table
+	Page int
+	// Header holds the cells of the header row or nil if none was detected
+	Header []Cell
+	// Rows holds the body rows. Every row has one cell per column, cells
+	// without words are empty.
+	Rows [][]Cell
+	// Columns are the horizontal extents of the columns
+	Columns []PageCoordinates
+}
+
+// Strings returns the cell texts of the header (if any) and all rows
+func (t *Table) Strings() [][]string {
+	var records [][]string
+
+	row := func(cells []Cell) []string {
+		record := make([]string, len(cells))
+		for i, cell := range cells {
+			record[i] = cell.Text
+		}
+		return record
+	}
+
+	if t.Header != nil {
+		records = append(records, row(t.Header))
+	}
+	for _, cells := range t.Rows {
+		records = append(records, row(cells))
+	}
+
+	return records
+}
+
+// WriteCSV writes the header (if any) and all rows as CSV
+func (t *Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(t.Strings()); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// tableRow is a visual row of words split into segments at wide gaps
+type tableRow struct {
+	PageCoordinates
+	segments []Cell
+	// continuation rows continue the cells of the previous row
+	continuation bool
+}
+
+// DetectTables finds tables on the page. Words are clustered into visual rows
+// by their vertical position and split into cells at gaps wider than the
+// font size. Consecutive rows with enough cells form a table, their cells
+// are aligned to columns. Rows with fewer cells and no numbers in between
+// (e.g. wrapped descriptions) are merged into the row above. The first row
+// is used as header if it contains no numbers and is set in bold or sits
+// above numeric columns.
+func (p *PageLayout) DetectTables(options TableOptions) []Table {
+	options = options.withDefaults()
+
+	var tables []Table
+	var block []tableRow
+
+	flush := func() {
+		// trailing continuation rows do not belong to the table
+		for len(block) > 0 && block[len(block)-1].continuation {
+			block = block[:len(block)-1]
+		}
+		if table, ok := buildTable(block, options); ok {
+			table.Page = p.Number
+			tables = append(tables, table)
+		}
+		block = nil
+	}
+
+	for _, row := range visualRows(p) {
+		if len(block) > 0 {
+			previous := block[len(block)-1]
+			if row.T-previous.Bottom() > 1.5*math.Max(row.H, previous.H) {
+				flush()
+			}
+		}
+
+		switch {
+		case len(row.segments) >= options.MinColumns:
+			block = append(block, row)
+		case len(block) > 0 && !hasNumber(row.segments):
+			row.continuation = true
+			block = append(block, row)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return tables
+}
+
+// visualRows clusters the words of a page into rows ordered top to bottom
+func visualRows(p *PageLayout) []tableRow {
+	var words []Word
+	for _, zone := range p.TextZones {
+		for _, paragraph := range zone.Paragraphs {
+			for _, line := range paragraph.Lines {
+				words = append(words, line.Words...)
+			}
+		}
+	}
+
+	center := func(w Word) float64 { return w.T + w.H/2 }
+	sort.SliceStable(words, func(i, j int) bool { return center(words[i]) < center(words[j]) })
+
+	var groups [][]Word
+	for _, w := range words {
+		if n := len(groups); n > 0 {
+			last := groups[n-1]
+			reference := last[len(last)-1]
+			if math.Abs(center(w)-center(reference)) <= math.Max(w.H, reference.H)/2 {
+				groups[n-1] = append(last, w)
+				continue
+			}
+		}
+		groups = append(groups, []Word{w})
+	}
+
+	rows := make([]tableRow, len(groups))
+	for i, group := range groups {
+		sort.SliceStable(group, func(a, b int) bool { return group[a].L < group[b].L })
+
+		var segment []Word
+		for j, w := range group {
+			if j > 0 {
+				previous := group[j-1]
+				if w.L-previous.Right() > math.Max(math.Max(w.H, previous.H), math.Max(w.Fontsize, previous.Fontsize)) {
+					rows[i].segments = append(rows[i].segments, newCell(segment))
+					segment = nil
+				}
+			}
+			segment = append(segment, w)
+		}
+		rows[i].segments = append(rows[i].segments, newCell(segment))
+		rows[i].PageCoordinates = union(rows[i].segments)
+	}
+
+	return rows
+}
+
+// buildTable aligns the segments of a block of rows to columns
+func buildTable(block []tableRow, options TableOptions) (Table, bool) {
+	if len(block) < options.MinRows {
+		return Table{}, false
+	}
+
+	// columns are the merged horizontal extents of the segments of the
+	// regular rows
+	var extents []PageCoordinates
+	for _, row := range block {
+		if row.continuation {
+			continue
+		}
+		for _, segment := range row.segments {
+			extents = append(extents, PageCoordinates{L: segment.L, W: segment.W})
+		}
+	}
+	sort.Slice(extents, func(i, j int) bool { return extents[i].L < extents[j].L })
+
+	var columns []PageCoordinates
+	for _, extent := range extents {
+		if n := len(columns); n > 0 && extent.L < columns[n-1].Right() {
+			columns[n-1].W = math.Max(columns[n-1].Right(), extent.Right()) - columns[n-1].L
+			continue
+		}
+		columns = append(columns, extent)
+	}
+
+	if len(columns) < options.MinColumns {
+		return Table{}, false
+	}
+
+	var rows [][]Cell
+	for _, row := range block {
+		cells := make([][]Word, len(columns))
+		for _, segment := range row.segments {
+			column := nearestColumn(columns, segment.PageCoordinates)
+			cells[column] = append(cells[column], segment.Words...)
+		}
+
+		if row.continuation && len(rows) > 0 {
+			previous := rows[len(rows)-1]
+			for i, words := range cells {
+				if len(words) > 0 {
+					previous[i] = newCell(append(previous[i].Words, words...))
+				}
+			}
+			continue
+		}
+
+		row := make([]Cell, len(columns))
+		for i, words := range cells {
+			row[i] = newCell(words)
+		}
+		rows = append(rows, row)
+	}
+
+	table := Table{Columns: columns}
+	if isHeader(rows) {
+		table.Header, rows = rows[0], rows[1:]
+	}
+	if len(rows) < options.MinRows {
+		return Table{}, false
+	}
+	table.Rows = rows
+
+	var all []Cell
+	all = append(all, table.Header...)
+	for _, row := range table.Rows {
+		all = append(all, row...)
+	}
+	table.PageCoordinates = union(all)
+
+	for i := range table.Columns {
+		table.Columns[i].T, table.Columns[i].H = table.T, table.H
+	}
+
+	return table, true
+}
+
+// nearestColumn returns the column overlapping c the most or the closest one
+func nearestColumn(columns []PageCoordinates, c PageCoordinates) int {
+	best, bestScore := 0, math.Inf(-1)
+	for i, column := range columns {
+		// positive overlap or negative distance
+		score := math.Min(c.Right(), column.Right()) - math.Max(c.L, column.L)
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// isHeader reports whether the first row is a header row
+func isHeader(rows [][]Cell) bool {
+	if len(rows) < 2 || hasNumber(rows[0]) {
+		return false
+	}
+
+	for _, cell := range rows[0] {
+		for _, w := range cell.Words {
+			if w.Bold {
+				return true
+			}
+		}
+	}
+
+	// a column that is numeric in the body but not in the first row
+	for column := range rows[0] {
+		numeric, filled := 0, 0
+		for _, row := range rows[1:] {
+			if row[column].Text == "" {
+				continue
+			}
+			filled++
+			if isNumber(row[column].Text) {
+				numeric++
+			}
+		}
+		if filled > 0 && numeric*2 > filled {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasNumber reports whether any of the cells holds a number
+func hasNumber(cells []Cell) bool {
+	for _, cell := range cells {
+		if isNumber(cell.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNumber reports whether text is a number, amount or percentage
+func isNumber(text string) bool {
+	digits := 0
+	for _, r := range text {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case strings.ContainsRune(" .,'-+%€$", r):
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+// newCell joins words to a cell
+func newCell(words []Word) Cell {
+	if len(words) == 0 {
+		return Cell{}
+	}
+
+	texts := make([]string, len(words))
+	cells := make([]Cell, len(words))
+	for i, w := range words {
+		texts[i] = w.Text
+		cells[i] = Cell{PageCoordinates: w.PageCoordinates}
+	}
+
+	return Cell{PageCoordinates: union(cells), Text: strings.Join(texts, " "), Words: words}
+}
+
+// union returns the bounding box of all non empty cells
+func union(cells []Cell) PageCoordinates {
+	var result PageCoordinates
+	found := false
+
+	for _, cell := range cells {
+		if cell.W == 0 && cell.H == 0 && len(cell.Words) == 0 {
+			continue
+		}
+		if !found {
+			result, found = cell.PageCoordinates, true
+			continue
+		}
+		left, top := math.Min(result.L, cell.L), math.Min(result.T, cell.T)
+		right, bottom := math.Max(result.Right(), cell.Right()), math.Max(result.Bottom(), cell.Bottom())
+		result = PageCoordinates{L: left, T: top, W: right - left, H: bottom - top}
+	}
+
+	return result
+}
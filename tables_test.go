@@ -0,0 +1,74 @@
+package giniapi
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// testRow builds a line of words at the given positions. Cells map the left
+// edge to the text, words are 6 points wide per character and 10 points high.
+func testRow(top float64, bold bool, cells map[float64]string) Paragraph {
+	p := Paragraph{}
+	for left, text := range cells {
+		line := testParagraph(left, top, text).Lines[0]
+		for i := range line.Words {
+			line.Words[i].W = float64(len(line.Words[i].Text)) * 6
+			line.Words[i].Fontsize = 10
+			line.Words[i].Bold = bold
+		}
+		p.Lines = append(p.Lines, line)
+	}
+	return p
+}
+
+func testTablePage() *PageLayout {
+	return &PageLayout{
+		Number: 2,
+		TextZones: []TextZone{{Paragraphs: []Paragraph{
+			testRow(40, false, map[float64]string{40: "Gini GmbH, Tumblingerstr. 32, 80337 München"}),
+			testRow(100, true, map[float64]string{40: "Pos", 80: "Beschreibung", 300: "Menge", 400: "Preis"}),
+			testRow(115, false, map[float64]string{40: "1", 80: "Beratung", 310: "2", 400: "24,99"}),
+			testRow(127, false, map[float64]string{80: "vor Ort"}),
+			testRow(139, false, map[float64]string{40: "2", 80: "Reisekosten", 310: "1", 400: "120,00"}),
+			testRow(151, false, map[float64]string{40: "3", 80: "Material", 400: "5,00"}),
+			testRow(163, false, map[float64]string{300: "Summe", 400: "174,98"}),
+			testRow(300, false, map[float64]string{40: "Zahlbar bis 24.12.2018"}),
+		}}},
+	}
+}
+
+func Test_DetectTables(t *testing.T) {
+	tables := testTablePage().DetectTables(TableOptions{})
+	assertEqual(t, len(tables), 1, "")
+
+	table := tables[0]
+	assertEqual(t, table.Page, 2, "")
+	assertEqual(t, len(table.Columns), 4, "")
+	assertEqual(t, fmt.Sprint(table.Strings()), "[[Pos Beschreibung Menge Preis] [1 Beratung vor Ort 2 24,99] [2 Reisekosten 1 120,00] [3 Material  5,00]]", "")
+	assertEqual(t, table.T, 100.0, "")
+	assertEqual(t, table.Rows[0][1].Bottom(), 137.0, "wrapped cell spans both lines")
+	assertEqual(t, len(table.Rows[2][2].Words), 0, "empty cell")
+
+	var buf bytes.Buffer
+	assertEqual(t, table.WriteCSV(&buf), nil, "")
+	assertEqual(t, buf.String(), "Pos,Beschreibung,Menge,Preis\n1,Beratung vor Ort,2,\"24,99\"\n2,Reisekosten,1,\"120,00\"\n3,Material,,\"5,00\"\n", "")
+}
+
+func Test_DetectTablesHeader(t *testing.T) {
+	page := testTablePage()
+
+	// without bold font the header is detected by the numeric columns below
+	for i := range page.TextZones[0].Paragraphs[1].Lines {
+		for j := range page.TextZones[0].Paragraphs[1].Lines[i].Words {
+			page.TextZones[0].Paragraphs[1].Lines[i].Words[j].Bold = false
+		}
+	}
+	tables := page.DetectTables(TableOptions{})
+	assertEqual(t, len(tables[0].Header), 4, "")
+
+	// tables need enough rows and columns
+	assertEqual(t, len(page.DetectTables(TableOptions{MinRows: 4})), 0, "")
+	assertEqual(t, len(page.DetectTables(TableOptions{MinColumns: 5})), 0, "")
+	assertEqual(t, len(testLayout(t).Pages[0].DetectTables(TableOptions{})), 0, "")
+}
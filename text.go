@@ -0,0 +1,245 @@
+package giniapi
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Separators used by the text reconstruction
+const (
+	paragraphSeparator = "\n\n"
+	pageSeparator      = "\f"
+)
+
+// WordOffset maps a word of the layout to its position in the text
+type WordOffset struct {
+	// Start and End are byte offsets of the word in the text. Hyphens
+	// removed at line breaks are not part of the text.
+	Start int
+	End   int
+	// Page number of the word
+	Page int
+	Word Word
+}
+
+// TextMap is the text of a layout with the position of every word
+type TextMap struct {
+	Text  string
+	Words []WordOffset
+}
+
+// WordsIn returns the words overlapping the text range [start, end)
+func (m *TextMap) WordsIn(start, end int) []WordOffset {
+	first := sort.Search(len(m.Words), func(i int) bool { return m.Words[i].End > start })
+
+	var words []WordOffset
+	for i := first; i < len(m.Words) && m.Words[i].Start < end; i++ {
+		words = append(words, m.Words[i])
+	}
+	return words
+}
+
+// WordAt returns the word at the byte offset of the text
+func (m *TextMap) WordAt(offset int) (WordOffset, bool) {
+	if words := m.WordsIn(offset, offset+1); len(words) > 0 {
+		return words[0], true
+	}
+	return WordOffset{}, false
+}
+
+// textBuilder writes text and records the offsets of the words
+type textBuilder struct {
+	strings.Builder
+	page  int
+	words []WordOffset
+}
+
+func (b *textBuilder) word(w Word, text string) {
+	start := b.Len()
+	b.WriteString(text)
+	b.words = append(b.words, WordOffset{Start: start, End: b.Len(), Page: b.page, Word: w})
+}
+
+func (b *textBuilder) textMap() *TextMap {
+	return &TextMap{Text: b.String(), Words: b.words}
+}
+
+// Text returns the words of the line separated by spaces
+func (l Line) Text() string {
+	var b textBuilder
+	b.writeLine(l)
+	return b.String()
+}
+
+func (b *textBuilder) writeLine(l Line) {
+	for i, w := range l.Words {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.word(w, w.Text)
+	}
+}
+
+// Text returns the lines of the paragraph separated by newlines. Words
+// hyphenated at the end of a line are joined.
+func (p Paragraph) Text() string {
+	var b textBuilder
+	b.writeParagraph(p)
+	return b.String()
+}
+
+func (b *textBuilder) writeParagraph(p Paragraph) {
+	separator := ""
+
+	for i, line := range p.Lines {
+		for j, w := range line.Words {
+			b.WriteString(separator)
+			separator = " "
+
+			last := j == len(line.Words)-1 && i < len(p.Lines)-1
+			if last && hyphenated(w.Text, p.Lines[i+1]) {
+				b.word(w, strings.TrimSuffix(w.Text, "-"))
+				separator = ""
+				continue
+			}
+			b.word(w, w.Text)
+		}
+		if separator != "" {
+			separator = "\n"
+		}
+	}
+}
+
+// hyphenated reports whether text is the first part of a word hyphenated at
+// the end of a line that continues with next
+func hyphenated(text string, next Line) bool {
+	if len(next.Words) == 0 || !strings.HasSuffix(text, "-") {
+		return false
+	}
+
+	before, _ := utf8.DecodeLastRuneInString(strings.TrimSuffix(text, "-"))
+	after, _ := utf8.DecodeRuneInString(next.Words[0].Text)
+
+	return unicode.IsLetter(before) && unicode.IsLower(after)
+}
+
+// Paragraphs returns the paragraphs of all text zones in reading order.
+// Columns are read top to bottom before moving on to the next column.
+func (p *PageLayout) Paragraphs() []Paragraph {
+	var paragraphs []Paragraph
+	for _, zone := range p.TextZones {
+		paragraphs = append(paragraphs, zone.Paragraphs...)
+	}
+
+	ordered := make([]Paragraph, 0, len(paragraphs))
+	xyCut(paragraphs, &ordered)
+	return ordered
+}
+
+// xyCut orders paragraphs by recursively splitting them at the widest
+// horizontal or vertical whitespace gap. Columns separated by a wide gap are
+// read one after the other, a header or footer is cut off by its gap.
+func xyCut(paragraphs []Paragraph, ordered *[]Paragraph) {
+	if len(paragraphs) <= 1 {
+		*ordered = append(*ordered, paragraphs...)
+		return
+	}
+
+	top := func(p Paragraph) (float64, float64) { return p.T, p.Bottom() }
+	left := func(p Paragraph) (float64, float64) { return p.L, p.Right() }
+
+	first, second, gap := splitAtGap(paragraphs, top)
+	if a, b, columnGap := splitAtGap(paragraphs, left); columnGap > gap {
+		first, second, gap = a, b, columnGap
+	}
+
+	if gap < 0 {
+		sort.SliceStable(paragraphs, func(i, j int) bool {
+			if paragraphs[i].T != paragraphs[j].T {
+				return paragraphs[i].T < paragraphs[j].T
+			}
+			return paragraphs[i].L < paragraphs[j].L
+		})
+		*ordered = append(*ordered, paragraphs...)
+		return
+	}
+
+	xyCut(first, ordered)
+	xyCut(second, ordered)
+}
+
+// splitAtGap sorts paragraphs along an axis and splits them at the widest gap
+// no paragraph spans. The gap is negative if there is none.
+func splitAtGap(paragraphs []Paragraph, span func(Paragraph) (float64, float64)) ([]Paragraph, []Paragraph, float64) {
+	sorted := make([]Paragraph, len(paragraphs))
+	copy(sorted, paragraphs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _ := span(sorted[i])
+		b, _ := span(sorted[j])
+		return a < b
+	})
+
+	cut, gap := 0, -1.0
+	_, end := span(sorted[0])
+
+	for i, p := range sorted[1:] {
+		start, stop := span(p)
+		if start >= end && start-end > gap {
+			cut, gap = i+1, start-end
+		}
+		if stop > end {
+			end = stop
+		}
+	}
+
+	return sorted[:cut], sorted[cut:], gap
+}
+
+// Text returns the text of the page in reading order. Paragraphs are
+// separated by blank lines.
+func (p *PageLayout) Text() string {
+	return p.TextMap().Text
+}
+
+// TextMap returns the text of the page with the position of every word
+func (p *PageLayout) TextMap() *TextMap {
+	b := textBuilder{page: p.Number}
+	b.writePage(p)
+	return b.textMap()
+}
+
+func (b *textBuilder) writePage(p *PageLayout) {
+	for i, paragraph := range p.Paragraphs() {
+		if i > 0 {
+			b.WriteString(paragraphSeparator)
+		}
+		b.writeParagraph(paragraph)
+	}
+}
+
+// Text returns the text of all pages in reading order. Pages are separated
+// by form feeds.
+func (l *Layout) Text() string {
+	return l.TextMap().Text
+}
+
+// TextMap returns the text of all pages with the position of every word
+func (l *Layout) TextMap() *TextMap {
+	pages := make([]*PageLayout, len(l.Pages))
+	for i := range l.Pages {
+		pages[i] = &l.Pages[i]
+	}
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].Number < pages[j].Number })
+
+	var b textBuilder
+	for i, p := range pages {
+		if i > 0 {
+			b.WriteString(pageSeparator)
+		}
+		b.page = p.Number
+		b.writePage(p)
+	}
+	return b.textMap()
+}
@@ -0,0 +1,102 @@
+package giniapi
+
+import (
+	"strings"
+	"testing"
+)
+
+// testParagraph builds a paragraph with one line per entry of lines. Words
+// are 10 points wide per character and lines 12 points high.
+func testParagraph(left, top float64, lines ...string) Paragraph {
+	p := Paragraph{PageCoordinates: PageCoordinates{L: left, T: top}}
+
+	for i, text := range lines {
+		line := Line{PageCoordinates: PageCoordinates{L: left, T: top + float64(i)*12, H: 10}}
+		x := left
+		for _, word := range strings.Fields(text) {
+			w := Word{PageCoordinates: PageCoordinates{L: x, T: line.T, W: float64(len(word)) * 10, H: 10}, Text: word}
+			line.Words = append(line.Words, w)
+			x += w.W + 5
+		}
+		line.W = x - left
+		if line.W > p.W {
+			p.W = line.W
+		}
+		p.Lines = append(p.Lines, line)
+	}
+	p.H = float64(len(lines)) * 12
+
+	return p
+}
+
+func Test_LayoutText(t *testing.T) {
+	assertEqual(t, testLayout(t).Text(), "Ihre Vorgangsnummer :", "")
+}
+
+func Test_ParagraphText(t *testing.T) {
+	p := testParagraph(0, 0, "Die Rech-", "nung ist bis zum", "24.12. zu zahlen, Gini-", "GmbH")
+	assertEqual(t, p.Text(), "Die Rechnung ist bis zum\n24.12. zu zahlen, Gini-\nGmbH", "")
+	assertEqual(t, p.Lines[1].Text(), "nung ist bis zum", "")
+}
+
+func Test_PageLayoutReadingOrder(t *testing.T) {
+	// a header above two columns and a footer, zones listed out of order
+	page := PageLayout{
+		Number: 1,
+		TextZones: []TextZone{
+			{Paragraphs: []Paragraph{
+				testParagraph(300, 100, "right column", "continues here"),
+				testParagraph(20, 500, "footer"),
+			}},
+			{Paragraphs: []Paragraph{
+				testParagraph(20, 100, "left column"),
+				testParagraph(20, 20, "header"),
+				testParagraph(20, 150, "left again"),
+				testParagraph(300, 160, "right end"),
+			}},
+		},
+	}
+
+	expected := "header\n\nleft column\n\nleft again\n\nright column\ncontinues here\n\nright end\n\nfooter"
+	assertEqual(t, page.Text(), expected, "")
+
+	// a header spanning both columns
+	page.TextZones[1].Paragraphs[1] = testParagraph(20, 20, "a header wide enough to span both columns")
+	assertEqual(t, strings.Index(page.Text(), "a header wide enough to span both columns\n\nleft column\n\nleft again\n\nright column"), 0, "")
+	page.TextZones[1].Paragraphs[1] = testParagraph(20, 20, "header")
+
+	layout := Layout{Pages: []PageLayout{{Number: 2, TextZones: []TextZone{{Paragraphs: []Paragraph{testParagraph(0, 0, "second")}}}}, page}}
+	assertEqual(t, layout.Text(), expected+"\fsecond", "")
+}
+
+func Test_TextMap(t *testing.T) {
+	page := PageLayout{
+		Number: 3,
+		TextZones: []TextZone{{Paragraphs: []Paragraph{
+			testParagraph(0, 0, "Betrag:", "24,99 EUR"),
+			testParagraph(0, 100, "Zahlungs-", "empfänger Gini"),
+		}}},
+	}
+
+	m := page.TextMap()
+	assertEqual(t, m.Text, "Betrag:\n24,99 EUR\n\nZahlungsempfänger Gini", "")
+	assertEqual(t, len(m.Words), 6, "")
+
+	start := strings.Index(m.Text, "24,99")
+	word, ok := m.WordAt(start + 2)
+	assertEqual(t, ok, true, "")
+	assertEqual(t, word.Word.Text, "24,99", "")
+	assertEqual(t, word.Page, 3, "")
+	assertEqual(t, word.Word.T, 12.0, "")
+
+	_, ok = m.WordAt(start - 1)
+	assertEqual(t, ok, false, "newline between words")
+
+	// the dehyphenated word maps to both parts
+	start = strings.Index(m.Text, "Zahlungsempfänger")
+	words := m.WordsIn(start, start+len("Zahlungsempfänger"))
+	assertEqual(t, len(words), 2, "")
+	assertEqual(t, words[0].Word.Text, "Zahlungs-", "")
+	assertEqual(t, m.Text[words[0].Start:words[0].End], "Zahlungs", "")
+	assertEqual(t, words[1].Word.Text, "empfänger", "")
+}
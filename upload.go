@@ -0,0 +1,180 @@
+package giniapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// UploadFile uploads the document at filePath. FileName defaults to the base
+// name of the path, ContentType to the type of the file extension or the
+// detected type of the content.
+func (api *APIClient) UploadFile(ctx context.Context, filePath string, options UploadOptions) (*Document, APIResponse) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, apiResponse(ErrUploadSource, "", nil, err)
+	}
+	defer f.Close()
+
+	if options.FileName == "" {
+		options.FileName = filepath.Base(filePath)
+	}
+	if options.ContentType == "" {
+		options.ContentType = mime.TypeByExtension(filepath.Ext(filePath))
+	}
+	if options.ContentType == "" {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(f, head)
+		options.ContentType = http.DetectContentType(head[:n])
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, apiResponse(ErrUploadSource, "", nil, err)
+		}
+	}
+
+	return api.Upload(ctx, f, options)
+}
+
+// UploadBytes uploads a document held in memory. ContentType defaults to the
+// detected type of the content.
+func (api *APIClient) UploadBytes(ctx context.Context, document []byte, options UploadOptions) (*Document, APIResponse) {
+	if options.ContentType == "" {
+		options.ContentType = http.DetectContentType(document)
+	}
+
+	return api.Upload(ctx, bytes.NewReader(document), options)
+}
+
+// UploadFromURL streams the document at documentURL to Gini without storing
+// it. The document is fetched with http.DefaultClient, so no Gini
+// credentials are sent to the source. FileName and ContentType default to
+// the Content-Disposition and Content-Type of the source or the last path
+// element of the URL.
+func (api *APIClient) UploadFromURL(ctx context.Context, documentURL string, options UploadOptions) (*Document, APIResponse) {
+	req, err := http.NewRequest("GET", documentURL, nil)
+	if err != nil {
+		return nil, apiResponse(ErrUploadSource, "", nil, err)
+	}
+
+	source, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, apiResponse(ErrUploadSource, "", source, err)
+	}
+	defer source.Body.Close()
+
+	if source.StatusCode != http.StatusOK {
+		return nil, apiResponse(ErrUploadSource, "", source, fmt.Errorf("%s: %s", ErrUploadSource, source.Status))
+	}
+
+	if options.FileName == "" {
+		if _, params, err := mime.ParseMediaType(source.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+			options.FileName = params["filename"]
+		} else if name := path.Base(req.URL.Path); name != "/" && name != "." {
+			options.FileName = name
+		}
+	}
+	if options.ContentType == "" {
+		if contentType, _, err := mime.ParseMediaType(source.Header.Get("Content-Type")); err == nil {
+			options.ContentType = contentType
+		}
+	}
+
+	var body io.Reader = source.Body
+	if source.ContentLength >= 0 {
+		body = &sizedReader{Reader: source.Body, size: source.ContentLength}
+	}
+
+	return api.Upload(ctx, body, options)
+}
+
+// sizedReader is a stream of known size, e.g. a response body
+type sizedReader struct {
+	io.Reader
+	size int64
+	read int64
+}
+
+func (s *sizedReader) Read(p []byte) (int, error) {
+	n, err := s.Reader.Read(p)
+	s.read += int64(n)
+	return n, err
+}
+
+// contentLength returns the number of bytes left in the stream
+func (s *sizedReader) contentLength() int64 {
+	return s.size - s.read
+}
+
+// uploadReader reports the progress of an upload and enforces the maximum
+// size of documents with unknown size while they are sent
+type uploadReader struct {
+	r        io.Reader
+	sent     int64
+	total    int64
+	max      int64
+	progress func(sent, total int64)
+	tooLarge bool
+}
+
+func newUploadReader(r io.Reader, options UploadOptions) *uploadReader {
+	return &uploadReader{
+		r:        r,
+		total:    readerSize(r),
+		max:      options.MaxSize,
+		progress: options.Progress,
+	}
+}
+
+// contentLength returns the number of bytes left to send or -1 if unknown
+func (u *uploadReader) contentLength() int64 {
+	if u.total < 0 {
+		return -1
+	}
+	return u.total - u.sent
+}
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	u.sent += int64(n)
+
+	if u.max > 0 && u.sent > u.max {
+		u.tooLarge = true
+		return 0, errors.New(ErrUploadTooLarge)
+	}
+
+	if n > 0 && u.progress != nil {
+		u.progress(u.sent, u.total)
+	}
+
+	return n, err
+}
+
+// readerSize returns the number of bytes left in r or -1 if unknown
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case lengthReader:
+		return v.contentLength()
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case io.Seeker:
+		current, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := v.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	}
+	return -1
+}
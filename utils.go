@@ -10,6 +10,13 @@ import (
 	"strconv"
 )
 
+// lengthReader is a request body that knows the number of bytes left to
+// read, or -1 if unknown. makeAPIRequest sends it as Content-Length.
+type lengthReader interface {
+	io.Reader
+	contentLength() int64
+}
+
 // MakeAPIRequest is a wrapper around http.NewRequest to create http
 // request and inject required headers, set timeout, ...
 func (api *APIClient) makeAPIRequest(ctx context.Context, verb, url string, body io.Reader, headers map[string]string, userIdentifier string) (*http.Response, error) {
@@ -18,6 +25,18 @@ func (api *APIClient) makeAPIRequest(ctx context.Context, verb, url string, body
 		return nil, fmt.Errorf("failed to create HTTP request: %s", err)
 	}
 
+	// net/http only knows the length of bytes and strings readers and takes
+	// a ContentLength of 0 as unknown unless the body is http.NoBody
+	if r, ok := body.(lengthReader); ok {
+		switch n := r.contentLength(); {
+		case n > 0:
+			req.ContentLength = n
+		case n == 0:
+			req.ContentLength = 0
+			req.Body = http.NoBody
+		}
+	}
+
 	if _, ok := headers["Accept"]; !ok {
 		req.Header.Add("Accept", fmt.Sprintf("application/vnd.gini.%s+json", api.Config.APIVersion))
 	}
@@ -0,0 +1,272 @@
+package giniapi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Violation describes a single failed validation rule for a label
+type Violation struct {
+	Label   string
+	Rule    string
+	Message string
+}
+
+// String representation of a violation
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s (%s)", v.Label, v.Message, v.Rule)
+}
+
+// Violations groups violations by label
+type Violations map[string][]Violation
+
+// Empty reports whether no rule was violated
+func (v Violations) Empty() bool {
+	return len(v) == 0
+}
+
+// Labels returns the labels with violations in alphabetical order
+func (v Violations) Labels() []string {
+	labels := make([]string, 0, len(v))
+	for label := range v {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// Rule is a single check on a set of extractions
+type Rule interface {
+	Validate(e *Extractions) []Violation
+}
+
+// RuleFunc adapts a plain function to the Rule interface
+type RuleFunc func(e *Extractions) []Violation
+
+// Validate satisfies the Rule interface for RuleFunc
+func (f RuleFunc) Validate(e *Extractions) []Violation {
+	return f(e)
+}
+
+// Validator runs a set of rules against extractions
+type Validator struct {
+	Rules []Rule
+}
+
+// NewValidator returns a Validator with the given rules
+func NewValidator(rules ...Rule) *Validator {
+	return &Validator{Rules: rules}
+}
+
+// Add appends rules to the validator
+func (v *Validator) Add(rules ...Rule) {
+	v.Rules = append(v.Rules, rules...)
+}
+
+// Validate runs all rules in order and returns the violations grouped by label
+func (v *Validator) Validate(e *Extractions) Violations {
+	violations := Violations{}
+
+	for _, rule := range v.Rules {
+		for _, violation := range rule.Validate(e) {
+			violations[violation.Label] = append(violations[violation.Label], violation)
+		}
+	}
+
+	return violations
+}
+
+// Validate is a shortcut to run the given rules against the extractions
+func (e *Extractions) Validate(rules ...Rule) Violations {
+	return NewValidator(rules...).Validate(e)
+}
+
+// InvoiceRules returns the built-in rules for payable invoices: amountToPay,
+// iban and paymentRecipient are required, the amount must be positive, IBAN and
+// BIC well-formed and the paymentDueDate must not be before the invoiceDate.
+func InvoiceRules() []Rule {
+	return []Rule{
+		RequiredLabels("amountToPay", "iban", "paymentRecipient"),
+		PositiveAmount("amountToPay"),
+		ValidIBAN("iban"),
+		ValidBIC("bic"),
+		DateAfter("paymentDueDate", "invoiceDate"),
+	}
+}
+
+// RequiredLabels fails for every label that is missing or has an empty value
+func RequiredLabels(labels ...string) Rule {
+	return RuleFunc(func(e *Extractions) []Violation {
+		var violations []Violation
+		for _, label := range labels {
+			if e.GetValue(label) == "" {
+				violations = append(violations, Violation{label, "required", "label is missing"})
+			}
+		}
+		return violations
+	})
+}
+
+// PositiveAmount fails if the label holds an amount that is invalid or not
+// greater than zero. Missing labels are ignored.
+func PositiveAmount(label string) Rule {
+	return valueRule(label, "positiveAmount", func(value string) string {
+		amount, err := ParseAmount(value)
+		if err != nil {
+			return err.Error()
+		}
+		if amount.Cents <= 0 {
+			return "amount must be greater than zero"
+		}
+		return ""
+	})
+}
+
+// ValidIBAN fails if the label holds an IBAN with a wrong length, format or
+// checksum. Missing labels are ignored.
+func ValidIBAN(label string) Rule {
+	return valueRule(label, "iban", func(value string) string {
+		if err := ValidateIBAN(value); err != nil {
+			return err.Error()
+		}
+		return ""
+	})
+}
+
+// ValidBIC fails if the label holds a malformed BIC. Missing labels are ignored.
+func ValidBIC(label string) Rule {
+	return valueRule(label, "bic", func(value string) string {
+		if err := ValidateBIC(value); err != nil {
+			return err.Error()
+		}
+		return ""
+	})
+}
+
+// MaxLength fails if the value of label is longer than max characters
+func MaxLength(label string, max int) Rule {
+	return valueRule(label, "maxLength", func(value string) string {
+		if utf8.RuneCountInString(value) > max {
+			return fmt.Sprintf("value exceeds %d characters", max)
+		}
+		return ""
+	})
+}
+
+// DateAfter fails if the date in label is before the date in reference. The
+// rule is skipped unless both labels are present.
+func DateAfter(label, reference string) Rule {
+	return RuleFunc(func(e *Extractions) []Violation {
+		value, refValue := e.GetValue(label), e.GetValue(reference)
+		if value == "" || refValue == "" {
+			return nil
+		}
+
+		date, err := ParseDate(value)
+		if err != nil {
+			return []Violation{{label, "dateAfter", err.Error()}}
+		}
+
+		refDate, err := ParseDate(refValue)
+		if err != nil {
+			return []Violation{{reference, "dateAfter", err.Error()}}
+		}
+
+		if date.Before(refDate) {
+			return []Violation{{label, "dateAfter", fmt.Sprintf("date is before %s", reference)}}
+		}
+
+		return nil
+	})
+}
+
+// valueRule builds a rule that checks the value of a single present label.
+// check returns an empty string for valid values.
+func valueRule(label, name string, check func(value string) string) Rule {
+	return RuleFunc(func(e *Extractions) []Violation {
+		value := e.GetValue(label)
+		if value == "" {
+			return nil
+		}
+
+		if message := check(value); message != "" {
+			return []Violation{{label, name, message}}
+		}
+
+		return nil
+	})
+}
+
+// ibanLengths contains the IBAN length per country code for SEPA countries
+var ibanLengths = map[string]int{
+	"AD": 24, "AT": 20, "BE": 16, "BG": 22, "CH": 21, "CY": 28, "CZ": 24,
+	"DE": 22, "DK": 18, "EE": 20, "ES": 24, "FI": 18, "FR": 27, "GB": 22,
+	"GI": 23, "GR": 27, "HR": 21, "HU": 28, "IE": 22, "IS": 26, "IT": 27,
+	"LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MT": 31, "NL": 18,
+	"NO": 15, "PL": 28, "PT": 25, "RO": 24, "SE": 24, "SI": 19, "SK": 24,
+	"SM": 27, "VA": 22,
+}
+
+var (
+	ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	bicPattern  = regexp.MustCompile(`^[A-Z]{4}[A-Z]{2}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+)
+
+// NormalizeIBAN removes spaces and converts the IBAN to upper case
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// ValidateIBAN checks format, country specific length and the mod 97 checksum
+// of an IBAN. Spaces are ignored.
+func ValidateIBAN(iban string) error {
+	iban = NormalizeIBAN(iban)
+
+	if !ibanPattern.MatchString(iban) {
+		return errors.New(ErrIBANInvalid)
+	}
+
+	if length, ok := ibanLengths[iban[:2]]; ok && len(iban) != length {
+		return fmt.Errorf("%s: %s IBANs have %d characters", ErrIBANInvalid, iban[:2], length)
+	}
+
+	// move country code and checksum to the end and replace letters by numbers
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(fmt.Sprintf("%d", r-'A'+10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	if new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return fmt.Errorf("%s: checksum mismatch", ErrIBANInvalid)
+	}
+
+	return nil
+}
+
+// ValidateBIC checks the format of a 8 or 11 character BIC
+func ValidateBIC(bic string) error {
+	if !bicPattern.MatchString(strings.ToUpper(strings.TrimSpace(bic))) {
+		return errors.New(ErrBICInvalid)
+	}
+	return nil
+}
+
+// ParseDate parses a date value as returned by Gini (YYYY-MM-DD)
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, errors.New(ErrDateInvalid)
+	}
+	return date, nil
+}
@@ -0,0 +1,69 @@
+package giniapi
+
+import (
+	"testing"
+)
+
+func testInvoiceExtractions() *Extractions {
+	return &Extractions{
+		Extractions: map[string]Extraction{
+			"amountToPay":      {Entity: "amount", Value: "24.99:EUR"},
+			"iban":             {Entity: "iban", Value: "DE89370400440532013000"},
+			"bic":              {Entity: "bic", Value: "COBADEFFXXX"},
+			"paymentRecipient": {Entity: "companyname", Value: "Gini GmbH"},
+			"invoiceDate":      {Entity: "date", Value: "2018-11-20"},
+			"paymentDueDate":   {Entity: "date", Value: "2018-12-04"},
+		},
+	}
+}
+
+func Test_ValidateInvoiceRules(t *testing.T) {
+	extractions := testInvoiceExtractions()
+
+	violations := extractions.Validate(InvoiceRules()...)
+	assertEqual(t, violations.Empty(), true, "")
+
+	extractions.Extractions["amountToPay"] = Extraction{Entity: "amount", Value: "0.00:EUR"}
+	extractions.Extractions["iban"] = Extraction{Entity: "iban", Value: "DE89370400440532013001"}
+	extractions.Extractions["paymentDueDate"] = Extraction{Entity: "date", Value: "2018-11-01"}
+	delete(extractions.Extractions, "paymentRecipient")
+
+	violations = extractions.Validate(InvoiceRules()...)
+
+	labels := violations.Labels()
+	assertEqual(t, len(labels), 4, "")
+	assertEqual(t, labels[0], "amountToPay", "")
+	assertEqual(t, labels[1], "iban", "")
+	assertEqual(t, labels[2], "paymentDueDate", "")
+	assertEqual(t, labels[3], "paymentRecipient", "")
+	assertEqual(t, violations["paymentRecipient"][0].Rule, "required", "")
+}
+
+func Test_ValidatorCustomRule(t *testing.T) {
+	validator := NewValidator(RequiredLabels("iban"))
+	validator.Add(RuleFunc(func(e *Extractions) []Violation {
+		if e.GetValue("paymentRecipient") != "ACME Corp" {
+			return []Violation{{"paymentRecipient", "knownRecipient", "unknown recipient"}}
+		}
+		return nil
+	}))
+
+	violations := validator.Validate(testInvoiceExtractions())
+
+	assertEqual(t, len(violations), 1, "")
+	assertEqual(t, violations["paymentRecipient"][0].String(), "paymentRecipient: unknown recipient (knownRecipient)", "")
+}
+
+func Test_ValidateIBAN(t *testing.T) {
+	assertEqual(t, ValidateIBAN("DE89 3704 0044 0532 0130 00"), nil, "")
+	assertEqual(t, ValidateIBAN("GB82WEST12345698765432"), nil, "")
+	assertNotEqual(t, ValidateIBAN("DE89370400440532013001"), nil, "")
+	assertNotEqual(t, ValidateIBAN("DE8937040044053201300"), nil, "")
+	assertNotEqual(t, ValidateIBAN("no iban"), nil, "")
+}
+
+func Test_ValidateBIC(t *testing.T) {
+	assertEqual(t, ValidateBIC("HYVEDEMMXXX"), nil, "")
+	assertEqual(t, ValidateBIC("COBADEFF"), nil, "")
+	assertNotEqual(t, ValidateBIC("COBADE"), nil, "")
+}